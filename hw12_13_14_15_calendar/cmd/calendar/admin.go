@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	instrumentedstorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/instrumented"
+)
+
+const (
+	adminActor = "cli"
+	dateLayout = "2006-01-02"
+	timeLayout = "2006-01-02T15:04"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrImportFailed   = errors.New("some events were not imported")
+)
+
+// exportedEvent is the JSON form used by events export and events import.
+type exportedEvent struct {
+	ID          int       `json:"id,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Date        time.Time `json:"date"`
+	Duration    string    `json:"duration"`
+	UserID      int       `json:"userId"`
+}
+
+// adminCLI runs operator commands against the configured storage through app.App.
+type adminCLI struct {
+	app *app.App
+	in  io.Reader
+	out io.Writer
+}
+
+func runAdminCommand(args []string) int {
+	conf, err := config.Load(config.Path(configFile))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// stdout carries command output such as exported JSON.
+	if conf.Logger.Output == logger.OutputStdout {
+		conf.Logger.Output = logger.OutputStderr
+	}
+	logg, err := logger.New(conf.Logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer logg.Close()
+
+	ctx := app.WithActor(context.Background(), adminActor)
+
+	backend, err := newBackend(ctx, conf)
+	if err != nil {
+		logg.Error("failed to init storage: " + err.Error())
+		return 1
+	}
+	storage := instrumentedstorage.New(backend, metrics.New(), strings.ToLower(conf.Storage.Type))
+	defer storage.Close(ctx)
+
+	if strings.EqualFold(conf.Storage.Type, config.StorageTypeMemory) {
+		logg.Warn("memory storage lives only as long as this command, changes will be lost")
+	}
+
+	cli := &adminCLI{app: app.New(logg.Named("app"), storage, conf.App), in: os.Stdin, out: os.Stdout}
+	if err := cli.run(ctx, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, flag.ErrHelp) || errors.Is(err, ErrUnknownCommand) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func (c *adminCLI) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+
+	switch args[0] {
+	case "events":
+		return c.events(ctx, args[1:])
+	case "trash":
+		if len(args) < 2 || args[1] != "purge" {
+			return fmt.Errorf("%w: use trash purge", ErrUnknownCommand)
+		}
+		return c.purgeTrash(ctx)
+	case "queue":
+		return c.queue(ctx, args[1:])
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, args[0])
+	}
+}
+
+func (c *adminCLI) events(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: use events list|create|update|delete|export|import", ErrUnknownCommand)
+	}
+
+	switch args[0] {
+	case "list":
+		return c.listEvents(ctx, args[1:])
+	case "create":
+		return c.saveEvent(ctx, args[1:], false)
+	case "update":
+		return c.saveEvent(ctx, args[1:], true)
+	case "delete":
+		return c.deleteEvent(ctx, args[1:])
+	case "export":
+		return c.exportEvents(ctx, args[1:])
+	case "import":
+		return c.importEvents(ctx, args[1:])
+	default:
+		return fmt.Errorf("%w: events %q", ErrUnknownCommand, args[0])
+	}
+}
+
+// rangeFlags registers -from and -to; without them every event of the user is selected.
+func rangeFlags(flags *flag.FlagSet) func() (time.Time, time.Time, error) {
+	from := flags.String("from", "", "first day, YYYY-MM-DD")
+	to := flags.String("to", "", "last day, YYYY-MM-DD")
+
+	return func() (time.Time, time.Time, error) {
+		dateFrom, dateTo := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+		var err error
+		if *from != "" {
+			if dateFrom, err = time.Parse(dateLayout, *from); err != nil {
+				return dateFrom, dateTo, err
+			}
+		}
+		if *to != "" {
+			if dateTo, err = time.Parse(dateLayout, *to); err != nil {
+				return dateFrom, dateTo, err
+			}
+			dateTo = dateTo.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return dateFrom, dateTo, nil
+	}
+}
+
+func (c *adminCLI) listEvents(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("events list", flag.ContinueOnError)
+	userID := flags.Int("user", 0, "user id")
+	dateRange := rangeFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dateFrom, dateTo, err := dateRange()
+	if err != nil {
+		return err
+	}
+
+	events, err := c.app.ListEvents(ctx, *userID, dateFrom, dateTo)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tDATE\tDURATION\tTITLE")
+	for _, event := range events {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", event.ID, event.Date.Format(timeLayout), event.Duration, event.Title)
+	}
+	return table.Flush()
+}
+
+// saveEvent creates an event, or with update changes the fields given by flags
+// on an existing one; fields left empty keep their current value, so a
+// description cannot be cleared this way.
+func (c *adminCLI) saveEvent(ctx context.Context, args []string, update bool) error {
+	name := "events create"
+	if update {
+		name = "events update"
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", name)
+		if update {
+			fmt.Fprintln(flags.Output(), "  only the given fields change, empty ones keep their current value")
+		}
+		flags.PrintDefaults()
+	}
+	id := flags.Int("id", 0, "event id, update only")
+	userID := flags.Int("user", 0, "user id")
+	title := flags.String("title", "", "title")
+	description := flags.String("description", "", "description")
+	date := flags.String("date", "", "start, YYYY-MM-DDTHH:MM or RFC 3339")
+	duration := flags.String("duration", "", "duration as a PostgreSQL interval, e.g. 01:30:00")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	start, err := parseTime(*date)
+	if err != nil {
+		return err
+	}
+
+	event := &storage.Event{
+		ID:          *id,
+		Title:       *title,
+		Description: *description,
+		Date:        start,
+		Duration:    *duration,
+		UserID:      *userID,
+	}
+
+	if update {
+		err = c.app.UpdateEvent(ctx, event)
+	} else {
+		err = c.app.CreateEvent(ctx, event)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.out, event.ID)
+	return nil
+}
+
+func (c *adminCLI) deleteEvent(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("events delete", flag.ContinueOnError)
+	id := flags.Int("id", 0, "event id")
+	userID := flags.Int("user", 0, "user id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return c.app.DeleteEvent(ctx, *id, *userID)
+}
+
+func (c *adminCLI) exportEvents(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("events export", flag.ContinueOnError)
+	userID := flags.Int("user", 0, "user id")
+	dateRange := rangeFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dateFrom, dateTo, err := dateRange()
+	if err != nil {
+		return err
+	}
+
+	events, err := c.app.ListEvents(ctx, *userID, dateFrom, dateTo)
+	if err != nil {
+		return err
+	}
+
+	exported := make([]exportedEvent, 0, len(events))
+	for _, event := range events {
+		exported = append(exported, exportedEvent{
+			ID:          event.ID,
+			Title:       event.Title,
+			Description: event.Description,
+			Date:        event.Date,
+			Duration:    event.Duration,
+			UserID:      event.UserID,
+		})
+	}
+
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(exported)
+}
+
+// importEvents creates every event of a JSON export read from stdin or -file.
+// Source IDs are not kept; events are created in batches, failures are reported
+// per event and the rest still import.
+func (c *adminCLI) importEvents(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("events import", flag.ContinueOnError)
+	file := flags.String("file", "", "JSON file, stdin when empty")
+	userID := flags.Int("user", 0, "import all events for this user instead of their own")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	in := c.in
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var events []exportedEvent
+	if err := json.NewDecoder(in).Decode(&events); err != nil {
+		return err
+	}
+
+	items := make([]storage.BatchItem, 0, len(events))
+	for _, imported := range events {
+		event := storage.Event{
+			Title:       imported.Title,
+			Description: imported.Description,
+			Date:        imported.Date,
+			Duration:    imported.Duration,
+			UserID:      imported.UserID,
+		}
+		if *userID != 0 {
+			event.UserID = *userID
+		}
+		items = append(items, storage.BatchItem{Op: storage.BatchCreate, Event: event})
+	}
+
+	var failed int
+	for start := 0; start < len(items); start += app.MaxBatchSize {
+		chunk := items[start:min(start+app.MaxBatchSize, len(items))]
+
+		results, err := c.app.ApplyBatch(ctx, chunk, false)
+		if err != nil {
+			return err
+		}
+
+		for i, result := range results {
+			if result.Err != nil {
+				failed++
+				fmt.Fprintf(c.out, "event %d (%q): %v\n", start+i, chunk[i].Event.Title, result.Err)
+			}
+		}
+	}
+
+	fmt.Fprintf(c.out, "imported %d of %d events\n", len(events)-failed, len(events))
+	if failed > 0 {
+		return ErrImportFailed
+	}
+	return nil
+}
+
+func (c *adminCLI) purgeTrash(ctx context.Context) error {
+	purged, err := c.app.PurgeTrash(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "purged %d events\n", purged)
+	return nil
+}
+
+func (c *adminCLI) queue(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("queue", flag.ContinueOnError)
+	limit := flags.Int("limit", app.DefaultDeliveriesPageSize, "due deliveries to show")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	state, err := c.app.QueueState(ctx, *limit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "pending deliveries: %d\ndue now: %d\n", state.Pending, len(state.Due))
+	if len(state.Due) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(c.out)
+	table := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tWEBHOOK\tEVENT\tTYPE\tATTEMPTS\tNEXT ATTEMPT\tLAST ERROR")
+	for _, delivery := range state.Due {
+		fmt.Fprintf(table, "%d\t%d\t%d\t%s\t%d\t%s\t%s\n",
+			delivery.ID, delivery.WebhookID, delivery.EventID, delivery.Type, delivery.Attempts,
+			delivery.NextAttemptAt.Format(time.RFC3339), delivery.Error)
+	}
+	return table.Flush()
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(timeLayout, value)
+}
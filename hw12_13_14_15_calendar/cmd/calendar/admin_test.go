@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminCLI(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	cli := &adminCLI{app: app.New(logg, storageService, config.AppConf{}), out: &out}
+	run := func(args ...string) error {
+		out.Reset()
+		return cli.run(ctx, args)
+	}
+
+	require.NoError(t, run("events", "create", "-user", "1", "-title", "Standup",
+		"-date", "2024-03-01T10:00", "-duration", "00:15:00"))
+	require.Equal(t, "1\n", out.String())
+
+	require.NoError(t, run("events", "update", "-user", "1", "-id", "1", "-title", "Daily standup",
+		"-date", "2024-03-01T10:30", "-duration", "00:15:00"))
+
+	require.NoError(t, run("events", "list", "-user", "1", "-from", "2024-03-01", "-to", "2024-03-01"))
+	require.Contains(t, out.String(), "2024-03-01T10:30")
+	require.Contains(t, out.String(), "Daily standup")
+
+	require.NoError(t, run("events", "export", "-user", "1"))
+	exported := out.String()
+	require.Contains(t, exported, `"title": "Daily standup"`)
+
+	cli.in = strings.NewReader(exported)
+	require.NoError(t, run("events", "import", "-user", "2"))
+	require.Contains(t, out.String(), "imported 1 of 1 events")
+
+	require.NoError(t, run("events", "list", "-user", "2"))
+	require.Contains(t, out.String(), "Daily standup")
+
+	require.NoError(t, run("events", "delete", "-user", "1", "-id", "1"))
+	require.NoError(t, run("events", "list", "-user", "1"))
+	require.NotContains(t, out.String(), "Daily standup")
+
+	require.NoError(t, run("trash", "purge"))
+	require.Equal(t, "purged 0 events\n", out.String())
+
+	require.NoError(t, run("queue"))
+	require.Contains(t, out.String(), "pending deliveries: 0")
+
+	require.ErrorIs(t, run("events", "rename"), ErrUnknownCommand)
+	require.ErrorIs(t, run("events", "delete", "-user", "1"), app.ErrEventIDRequired)
+}
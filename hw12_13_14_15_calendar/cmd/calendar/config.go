@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+)
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  version        print build information")
+	fmt.Fprintln(out, "  events         list, create, update, delete, import or export events")
+	fmt.Fprintln(out, "  trash purge    remove events past the restore window")
+	fmt.Fprintln(out, "  queue          show the webhook delivery queue")
+	fmt.Fprintln(out, "  config print   print the effective config with secrets masked")
+	fmt.Fprintln(out, "  config check   validate the config and exit")
+	fmt.Fprintln(out, "  config env     list the environment variables overriding the config")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func runConfigCommand(args []string) int {
+	if len(args) == 0 {
+		flag.Usage()
+		return 2
+	}
+
+	if args[0] == "env" {
+		description, err := config.EnvDescription()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(description)
+		return 0
+	}
+
+	conf, err := config.Load(config.Path(configFile))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch args[0] {
+	case "print":
+		if err := conf.Masked().WriteYAML(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "check":
+		fmt.Println("config is valid")
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config command %q\n", args[0])
+		return 2
+	}
+
+	return 0
+}
@@ -2,87 +2,222 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
-	"time"
 
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/health"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/leader"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/lifecycle"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/notify"
 	internalhttp "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/server/http"
+	cachedstorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/cached"
+	instrumentedstorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/instrumented"
 	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
 	sqlstorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/webhook"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/migrations"
 )
 
-const (
-	StorageTypeSql    = "SQL"
-	StorageTypeMemory = "MEMORY"
-)
+var ErrMigrationsPending = errors.New("database migrations pending")
+
+var configFile string
+
+func init() {
+	flag.StringVar(&configFile, "config", "", "path to config file (default $"+config.PathEnv+")")
+}
 
 func main() {
-	if flag.Arg(0) == "version" {
+	flag.Usage = usage
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "version":
 		printVersion()
 		return
+	case "config":
+		os.Exit(runConfigCommand(flag.Args()[1:]))
+	case "events", "trash", "queue":
+		os.Exit(runAdminCommand(flag.Args()))
+	case "":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	conf, err := config.NewConfig()
+	os.Exit(run())
+}
+
+// run serves until SIGINT or SIGTERM and returns the exit code; it never calls
+// os.Exit itself so that every deferred cleanup runs.
+func run() int {
+	conf, err := config.Load(config.Path(configFile))
 	if err != nil {
-		log.Fatalf("load config error: " + err.Error())
+		log.Print("load config error: " + err.Error())
+		return 1
 	}
 
-	logg := logger.New(conf.Logger.Level)
-
-	var storage app.StorageService
-	ctx := context.Background()
+	logg, err := logger.New(conf.Logger)
+	if err != nil {
+		log.Print("init logger error: " + err.Error())
+		return 1
+	}
+	defer logg.Close()
 
-	switch conf.Storage.Type {
-	case StorageTypeMemory:
-		storage, err = memorystorage.New()
-	case StorageTypeSql:
-		storage, err = sqlstorage.New(ctx, conf.DB)
+	exporter, err := tracing.NewExporter(conf.Tracing.Exporter, conf.Tracing.File)
+	if err != nil {
+		logg.Error("init tracing error: " + err.Error())
+		return 1
+	}
+	if exporter != nil {
+		tracing.SetTracer(tracing.NewTracer(conf.Tracing.Service, exporter, func(err error) {
+			logg.Warn("failed to export span: " + err.Error())
+		}))
+		defer exporter.Close()
 	}
 
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// SIGHUP is caught from here on; left to the default action, one arriving
+	// before the reloader runs would terminate the process.
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	checker := health.New(conf.Health.Timeout)
+
+	backend, err := newBackend(ctx, conf)
 	if err != nil {
 		logg.Error("failed to init storage: " + err.Error())
-		os.Exit(1) //nolint:gocritic
+		return 1
+	}
+	if sqlStorage, ok := backend.(*sqlstorage.Storage); ok {
+		checker.Add("migrations", migrationsCheck(sqlStorage), 0)
 	}
 
-	defer func() {
-		if closer, ok := storage.(sqlstorage.Closer); ok {
-			err := closer.Close(ctx)
-			if err != nil {
-				log.Printf("Error closing storage: %v", err)
-			}
-		}
-	}()
+	appMetrics := metrics.New()
+	storage := instrumentedstorage.New(backend, appMetrics, strings.ToLower(conf.Storage.Type))
 
-	calendar := app.New(logg, storage)
+	// storage is the first component, so it is closed last, once nothing uses it
+	components := lifecycle.New(logg.Named("lifecycle"), conf.Shutdown.Timeout)
+	components.Add(lifecycle.Component{Name: "storage", Stop: storage.Close})
 
-	server := internalhttp.NewServer(logg, calendar, conf.HTTP)
+	checker.Add("storage", storage.Ping, 0)
+	checker.Add("webhookQueue", func(ctx context.Context) error {
+		_, err := storage.PendingDeliveries(ctx)
+		return err
+	}, 0)
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-	defer cancel()
+	// Only the API reads through the cache; background workers see storage directly.
+	var appStorage app.StorageService = storage
+	if conf.App.Cache.Enabled {
+		appStorage = cachedstorage.New(storage, conf.App.Cache, appMetrics)
+	}
+	calendar := app.New(logg.Named("app"), appStorage, conf.App)
 
-	go func() {
-		<-ctx.Done()
+	server, err := internalhttp.NewServer(logg.Named("http"), calendar, conf.HTTP, appMetrics, checker, logg)
+	if err != nil {
+		logg.Error("failed to init http server: " + err.Error())
+		_ = storage.Close(ctx)
+		return 1
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+	worker := webhook.New(logg.Named("webhook"), storage, conf.Webhooks, appMetrics)
 
-		if err := server.Stop(ctx); err != nil {
-			logg.Error("failed to stop http server: " + err.Error())
-		}
-	}()
+	sender, err := notify.New(logg.Named("notify"), storage, conf.Notifications, appMetrics)
+	if err != nil {
+		logg.Error("failed to init notification sender: " + err.Error())
+		_ = storage.Close(ctx)
+		return 1
+	}
+
+	elector := leader.New(logg.Named("leader"), newLeaderLock(conf), conf.Leader, appMetrics)
+	reload := &reloader{
+		path:    config.Path(configFile),
+		hangup:  hangup,
+		logger:  logg,
+		current: conf,
+		server:  server,
+		worker:  worker,
+		sender:  sender,
+	}
+
+	components.Add(
+		lifecycle.Background("trash purge", calendar.RunTrashPurge),
+		lifecycle.Background("scheduler", func(ctx context.Context) {
+			elector.Run(ctx, worker.Run, sender.Run)
+		}),
+		lifecycle.Background("config reload", reload.Run),
+		lifecycle.Component{Name: "http", Run: server.Start, Stop: server.Stop},
+	)
 
 	logg.Info("calendar is running...")
 
-	if err := server.Start(ctx); err != nil {
-		logg.Error("failed to start http server: " + err.Error())
-		cancel()
-		os.Exit(1) //nolint:gocritic
+	if err := components.Run(ctx); err != nil {
+		logg.Error("calendar stopped with errors: " + err.Error())
+		return 1
+	}
+
+	logg.Info("calendar stopped")
+	return 0
+}
+
+func newBackend(ctx context.Context, conf *config.Config) (instrumentedstorage.Backend, error) {
+	switch strings.ToUpper(conf.Storage.Type) {
+	case config.StorageTypeSQL:
+		sqlStorage, err := sqlstorage.New(ctx, conf.DB)
+		if err != nil {
+			return nil, err
+		}
+		return sqlStorage, nil
+	default:
+		return memorystorage.New()
+	}
+}
+
+// newLeaderLock shares the scheduler lock through the database for SQL storage
+// and through a local file otherwise, as memory storage is never shared across hosts.
+func newLeaderLock(conf *config.Config) leader.Lock {
+	if strings.EqualFold(conf.Storage.Type, config.StorageTypeSQL) {
+		return sqlstorage.NewAdvisoryLock(conf.DB, conf.Leader.Name)
+	}
+
+	path := conf.Leader.LockFile
+	if path == "" {
+		path = filepath.Join(os.TempDir(), conf.Leader.Name+".lock")
+	}
+	return leader.NewFileLock(path)
+}
+
+// migrationsCheck fails readiness until the database has every migration bundled with the binary.
+func migrationsCheck(sqlStorage *sqlstorage.Storage) health.Check {
+	return func(ctx context.Context) error {
+		expected, err := migrations.Latest()
+		if err != nil {
+			return err
+		}
+
+		applied, err := sqlStorage.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		if applied < expected {
+			return fmt.Errorf("%w: applied %d, expected %d", ErrMigrationsPending, applied, expected)
+		}
+		return nil
 	}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/notify"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/webhook"
+)
+
+// reloadable lists the config sections applied on SIGHUP; changes elsewhere need a restart.
+var reloadable = []string{"logger.level", "logger.packages", "http.rateLimit", "webhooks", "notifications"}
+
+type rateLimitUpdater interface {
+	UpdateRateLimits(conf config.RateLimitConf)
+}
+
+type reloader struct {
+	path    string
+	hangup  <-chan os.Signal
+	logger  logger.Logger
+	current *config.Config
+	server  rateLimitUpdater
+	worker  *webhook.Worker
+	sender  *notify.Sender
+}
+
+// Run reloads the config on every signal from hangup, which run() registers
+// for SIGHUP before starting any component.
+func (r *reloader) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.hangup:
+			r.reload()
+		}
+	}
+}
+
+// reload re-reads the config file and applies the reloadable settings; an
+// invalid file is rejected and the running config stays in effect.
+func (r *reloader) reload() {
+	updated, err := config.Load(r.path)
+	if err != nil {
+		r.logger.Error("config reload rejected, keeping current config: " + err.Error())
+		return
+	}
+
+	// everything that can fail is prepared before anything is applied
+	applyLevels, err := r.logger.PrepareLevels(updated.Logger.Level, updated.Logger.Packages)
+	if err != nil {
+		r.logger.Error("config reload rejected, keeping current config: " + err.Error())
+		return
+	}
+	applyNotifications, err := r.sender.PrepareConfig(updated.Notifications)
+	if err != nil {
+		r.logger.Error("config reload rejected, keeping current config: " + err.Error())
+		return
+	}
+
+	applyLevels()
+	applyNotifications()
+	r.server.UpdateRateLimits(updated.HTTP.RateLimit)
+	r.worker.SetConfig(updated.Webhooks)
+
+	changes := config.Diff(r.current, updated)
+	for _, change := range changes {
+		if isReloadable(change.Path) {
+			r.logger.Info("config changed", "setting", change.Path, "old", change.Old, "new", change.New)
+		} else {
+			r.logger.Warn("config change requires restart", "setting", change.Path, "old", change.Old, "new", change.New)
+		}
+	}
+
+	r.current = updated
+	r.logger.Info("config reloaded", "changes", len(changes))
+}
+
+func isReloadable(path string) bool {
+	for _, prefix := range reloadable {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package app
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor attaches the identity of whoever performs the request; storages
+// record it in event history.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	if actor == "" {
+		return "system"
+	}
+	return actor
+}
@@ -2,10 +2,15 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
 )
 
 var (
@@ -17,6 +22,8 @@ var (
 	ErrDateRequired     = errors.New("date is required")
 	ErrDurationRequired = errors.New("duration is required")
 	ErrTitleRequired    = errors.New("title is required")
+	ErrSearchTextEmpty  = errors.New("search text is required")
+	ErrEventNotFound    = errors.New("event not found")
 )
 
 const (
@@ -25,9 +32,19 @@ const (
 	MONTH
 )
 
+const (
+	DefaultPageSize    = 50
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
 type App struct {
-	logger  Logger
-	storage StorageService
+	logger     Logger
+	storage    StorageService
+	pagination config.PaginationConf
+	changeFeed config.ChangeFeedConf
+	trash      config.TrashConf
+	cursors    cursorSigner
 }
 
 type Logger interface {
@@ -42,38 +59,213 @@ type StorageService interface {
 	UpdateEvent(ctx context.Context, updated *storage.Event) error
 	DeleteEvent(ctx context.Context, id int, userID int) error
 	ListEvents(ctx context.Context, userID int, dateFrom time.Time, dateTo time.Time) ([]storage.Event, error)
+	ListEventsPage(ctx context.Context, query storage.ListQuery) ([]storage.Event, error)
+	SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchResult, error)
+	ListChanges(ctx context.Context, userID int, after int64, limit int) ([]storage.Change, error)
+	WebhookStorage
+	TrashStorage
+	HistoryStorage
+	NotificationStorage
+	ReminderStorage
+	BatchStorage
+}
+
+type EventsPage struct {
+	Events     []storage.Event
+	NextCursor string
 }
 
-func New(logger Logger, storage StorageService) *App {
+func New(logger Logger, storage StorageService, conf config.AppConf) *App {
+	secret := []byte(conf.Pagination.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, sha256.Size)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+		logger.Warn("pagination secret is not configured, page cursors will not survive restart")
+	}
+
 	return &App{
-		logger:  logger,
-		storage: storage,
+		logger:     logger,
+		storage:    storage,
+		pagination: conf.Pagination,
+		changeFeed: conf.ChangeFeed,
+		trash:      conf.Trash,
+		cursors:    cursorSigner{secret: secret},
 	}
 }
 
-func (a *App) CreateEvent(ctx context.Context, event *storage.Event) error {
+func (a *App) CreateEvent(ctx context.Context, event *storage.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "App.CreateEvent")
+	defer span.End(&err)
+
 	return a.storage.AddEvent(ctx, event)
 }
 
+func (a *App) UpdateEvent(ctx context.Context, event *storage.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "App.UpdateEvent")
+	defer span.End(&err)
+
+	if event.UserID == 0 {
+		return ErrUserIDRequired
+	}
+	if event.ID == 0 {
+		return ErrEventIDRequired
+	}
+
+	return a.storage.UpdateEvent(ctx, event)
+}
+
+func (a *App) DeleteEvent(ctx context.Context, id int, userID int) (err error) {
+	ctx, span := tracing.Start(ctx, "App.DeleteEvent")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return ErrUserIDRequired
+	}
+	if id == 0 {
+		return ErrEventIDRequired
+	}
+
+	return a.storage.DeleteEvent(ctx, id, userID)
+}
+
 func (a *App) GetEventsForRange(
 	ctx context.Context, userID int, dateFrom time.Time, dateRange int,
-) ([]storage.Event, error) {
-	var dateTo time.Time
+) (_ []storage.Event, err error) {
+	ctx, span := tracing.Start(ctx, "App.GetEventsForRange")
+	defer span.End(&err)
+
+	dateTo, err := rangeEnd(dateFrom, dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	listEvents, err := a.storage.ListEvents(ctx, userID, dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	return listEvents, nil
+}
+
+// ListEvents returns a user's events between two arbitrary dates.
+func (a *App) ListEvents(
+	ctx context.Context, userID int, dateFrom time.Time, dateTo time.Time,
+) (_ []storage.Event, err error) {
+	ctx, span := tracing.Start(ctx, "App.ListEvents")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+	if dateTo.Before(dateFrom) {
+		return nil, ErrDateRange
+	}
+
+	return a.storage.ListEvents(ctx, userID, dateFrom, dateTo)
+}
+
+func (a *App) GetEventsPage(
+	ctx context.Context, userID int, dateFrom time.Time, dateRange int, cursor string, limit int,
+) (_ *EventsPage, err error) {
+	ctx, span := tracing.Start(ctx, "App.GetEventsPage")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	dateTo, err := rangeEnd(dateFrom, dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	query := storage.ListQuery{
+		UserID:   userID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+		Limit:    a.pageSize(limit) + 1,
+	}
+
+	if cursor != "" {
+		query.After, err = a.cursors.decode(userID, cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := a.storage.ListEventsPage(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &EventsPage{Events: events}
+	if len(events) == query.Limit {
+		page.Events = events[:len(events)-1]
+		last := page.Events[len(page.Events)-1]
+
+		page.NextCursor, err = a.cursors.encode(userID, storage.Cursor{Date: last.Date, ID: last.ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+func (a *App) pageSize(limit int) int {
+	if limit <= 0 {
+		limit = a.pagination.DefaultSize
+	}
+	if a.pagination.MaxSize > 0 && limit > a.pagination.MaxSize {
+		limit = a.pagination.MaxSize
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	return limit
+}
+
+func rangeEnd(dateFrom time.Time, dateRange int) (time.Time, error) {
 	switch dateRange {
 	case DAY:
-		dateTo = dateFrom.AddDate(0, 0, 1)
+		return dateFrom.AddDate(0, 0, 1), nil
 	case WEEK:
-		dateTo = dateFrom.AddDate(0, 0, 7)
+		return dateFrom.AddDate(0, 0, 7), nil
 	case MONTH:
-		dateTo = dateFrom.AddDate(0, 1, 0)
+		return dateFrom.AddDate(0, 1, 0), nil
 	default:
+		return time.Time{}, ErrDateRange
+	}
+}
+
+func (a *App) SearchEvents(ctx context.Context, query storage.SearchQuery) (_ []storage.SearchResult, err error) {
+	ctx, span := tracing.Start(ctx, "App.SearchEvents")
+	defer span.End(&err)
+
+	if query.UserID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return nil, ErrSearchTextEmpty
+	}
+
+	if !query.DateFrom.IsZero() && !query.DateTo.IsZero() && query.DateTo.Before(query.DateFrom) {
 		return nil, ErrDateRange
 	}
 
-	listEvents, err := a.storage.ListEvents(ctx, userID, dateFrom, dateTo)
-	if err != nil {
-		return nil, err
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	if query.Limit > MaxSearchLimit {
+		query.Limit = MaxSearchLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
 	}
 
-	return listEvents, nil
+	return a.storage.SearchEvents(ctx, query)
 }
@@ -0,0 +1,201 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp(t *testing.T) (*app.App, *memorystorage.Storage) {
+	t.Helper()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	conf := config.AppConf{
+		Pagination: config.PaginationConf{Secret: "test", DefaultSize: 2, MaxSize: 3},
+	}
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	return app.New(logg, storageService, conf), storageService
+}
+
+func TestGetEventsPage(t *testing.T) {
+	ctx := context.Background()
+	calendar, storageService := newTestApp(t)
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		// Pairs of events share a start time to exercise the id tie-breaker.
+		err := storageService.AddEvent(ctx, &storage.Event{
+			UserID:   1,
+			Title:    "Event",
+			Duration: "1:00:00",
+			Date:     day.Add(time.Duration(i/2) * time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Walk Pages", func(t *testing.T) {
+		var (
+			ids    []int
+			cursor string
+			pages  int
+		)
+
+		for {
+			page, err := calendar.GetEventsPage(ctx, 1, day, app.DAY, cursor, 0)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Events), 2)
+
+			for _, event := range page.Events {
+				ids = append(ids, event.ID)
+			}
+			pages++
+
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+
+		require.Equal(t, 4, pages)
+		require.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, ids)
+	})
+
+	t.Run("Max Page Size", func(t *testing.T) {
+		page, err := calendar.GetEventsPage(ctx, 1, day, app.DAY, "", 100)
+		require.NoError(t, err)
+		require.Len(t, page.Events, 3)
+	})
+
+	t.Run("Tampered Cursor", func(t *testing.T) {
+		page, err := calendar.GetEventsPage(ctx, 1, day, app.DAY, "", 0)
+		require.NoError(t, err)
+
+		_, err = calendar.GetEventsPage(ctx, 1, day, app.DAY, page.NextCursor+"x", 0)
+		require.ErrorIs(t, err, app.ErrInvalidCursor)
+	})
+
+	t.Run("Cursor Bound To User", func(t *testing.T) {
+		page, err := calendar.GetEventsPage(ctx, 1, day, app.DAY, "", 0)
+		require.NoError(t, err)
+
+		_, err = calendar.GetEventsPage(ctx, 2, day, app.DAY, page.NextCursor, 0)
+		require.ErrorIs(t, err, app.ErrInvalidCursor)
+	})
+}
+
+func TestSetNotificationPreferences(t *testing.T) {
+	ctx := context.Background()
+	calendar, _ := newTestApp(t)
+
+	for _, tc := range []struct {
+		name        string
+		preferences storage.NotificationPreferences
+		err         error
+	}{
+		{
+			name:        "unknown channel",
+			preferences: storage.NotificationPreferences{UserID: 1, Channels: []storage.Channel{"pigeon"}},
+			err:         app.ErrNotificationChannel,
+		},
+		{
+			name:        "email without address",
+			preferences: storage.NotificationPreferences{UserID: 1, Channels: []storage.Channel{storage.ChannelEmail}},
+			err:         app.ErrNotificationEmail,
+		},
+		{
+			name:        "half quiet hours",
+			preferences: storage.NotificationPreferences{UserID: 1, QuietStart: "22:00"},
+			err:         app.ErrQuietHours,
+		},
+		{
+			name:        "unknown timezone",
+			preferences: storage.NotificationPreferences{UserID: 1, Timezone: "Mars/Olympus"},
+			err:         app.ErrTimezone,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, calendar.SetNotificationPreferences(ctx, &tc.preferences), tc.err)
+		})
+	}
+
+	preferences := &storage.NotificationPreferences{
+		UserID:     1,
+		Channels:   []storage.Channel{storage.ChannelEmail, storage.ChannelLog},
+		Email:      "User <user@example.com>",
+		QuietStart: "22:00",
+		QuietEnd:   "07:00",
+		Timezone:   "Europe/Moscow",
+	}
+	require.NoError(t, calendar.SetNotificationPreferences(ctx, preferences))
+
+	stored, err := calendar.GetNotificationPreferences(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, preferences.Channels, stored.Channels)
+	require.Equal(t, "user@example.com", stored.Email, "stored as a bare SMTP address")
+
+	_, err = calendar.GetNotificationPreferences(ctx, 2)
+	require.ErrorIs(t, err, app.ErrPreferencesNotFound)
+}
+
+func TestSetReminders(t *testing.T) {
+	ctx := context.Background()
+	calendar, storageService := newTestApp(t)
+
+	event := &storage.Event{UserID: 1, Title: "Review", Duration: "1:00:00", Date: time.Now().Add(time.Hour)}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+
+	tooMany := make([]storage.Reminder, app.MaxReminders+1)
+	for i := range tooMany {
+		tooMany[i] = storage.Reminder{Offset: time.Duration(i+1) * time.Minute}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		reminders []storage.Reminder
+		err       error
+	}{
+		{name: "negative offset", reminders: []storage.Reminder{{Offset: -time.Minute}}, err: app.ErrReminderOffset},
+		{name: "offset too far", reminders: []storage.Reminder{{Offset: 8 * 24 * time.Hour}}, err: app.ErrReminderOffset},
+		{
+			name:      "unknown channel",
+			reminders: []storage.Reminder{{Offset: time.Hour, Channel: "pigeon"}},
+			err:       app.ErrNotificationChannel,
+		},
+		{
+			name:      "duplicate",
+			reminders: []storage.Reminder{{Offset: time.Hour}, {Offset: time.Hour}},
+			err:       app.ErrReminderDuplicate,
+		},
+		{name: "too many", reminders: tooMany, err: app.ErrTooManyReminders},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, calendar.SetReminders(ctx, event.ID, 1, tc.reminders), tc.err)
+		})
+	}
+
+	reminders := []storage.Reminder{
+		{Offset: 24 * time.Hour, Channel: storage.ChannelEmail},
+		{Offset: 10 * time.Minute},
+	}
+	require.NoError(t, calendar.SetReminders(ctx, event.ID, 1, reminders))
+
+	stored, err := calendar.ListReminders(ctx, event.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+
+	require.ErrorIs(t, calendar.SetReminders(ctx, event.ID, 2, reminders), app.ErrEventNotFound)
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const MaxBatchSize = 1000
+
+var (
+	ErrBatchEmpty     = errors.New("batch has no operations")
+	ErrBatchTooLarge  = errors.New("batch has too many operations")
+	ErrBatchOperation = errors.New("unknown batch operation")
+	ErrBatchAborted   = errors.New("not applied, another operation of the all-or-nothing batch failed")
+)
+
+type BatchStorage interface {
+	ApplyBatch(ctx context.Context, items []storage.BatchItem, atomic bool) ([]storage.BatchResult, error)
+}
+
+// ApplyBatch creates, updates and deletes events in one storage transaction
+// and returns a result per item. In atomic mode a single failing item leaves
+// every event untouched and the others report ErrBatchAborted; otherwise the
+// valid items are applied regardless of the failing ones.
+func (a *App) ApplyBatch(
+	ctx context.Context, items []storage.BatchItem, atomic bool,
+) (_ []storage.BatchResult, err error) {
+	ctx, span := tracing.Start(ctx, "App.ApplyBatch")
+	defer span.End(&err)
+
+	if len(items) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if len(items) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	results := make([]storage.BatchResult, len(items))
+	valid := make([]storage.BatchItem, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].Event = item.Event
+		if err := validateBatchItem(item); err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, item)
+		indexes = append(indexes, i)
+	}
+
+	if atomic && len(valid) < len(items) {
+		for _, i := range indexes {
+			results[i].Err = ErrBatchAborted
+		}
+		return results, nil
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	applied, err := a.storage.ApplyBatch(ctx, valid, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range indexes {
+		results[i] = applied[j]
+	}
+
+	return results, nil
+}
+
+func validateBatchItem(item storage.BatchItem) error {
+	event := item.Event
+	if event.UserID == 0 {
+		return ErrUserIDRequired
+	}
+
+	switch item.Op {
+	case storage.BatchCreate:
+		switch {
+		case event.Title == "":
+			return ErrTitleRequired
+		case event.Date.IsZero():
+			return ErrDateRequired
+		case event.Duration == "":
+			return ErrDurationRequired
+		}
+	case storage.BatchUpdate, storage.BatchDelete:
+		if event.ID == 0 {
+			return ErrEventIDRequired
+		}
+	default:
+		return ErrBatchOperation
+	}
+
+	return nil
+}
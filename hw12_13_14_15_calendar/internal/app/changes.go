@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const (
+	defaultChangesPollInterval = time.Second
+	defaultChangesBatchSize    = 100
+)
+
+// StreamChanges delivers the user's changes with ids greater than after to send,
+// in order, polling storage until ctx is done or send fails.
+func (a *App) StreamChanges(
+	ctx context.Context, userID int, after int64, send func(change storage.Change) error,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "App.StreamChanges")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return ErrUserIDRequired
+	}
+
+	interval := a.changeFeed.PollInterval
+	if interval <= 0 {
+		interval = defaultChangesPollInterval
+	}
+	batchSize := a.changeFeed.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultChangesBatchSize
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := a.storage.ListChanges(ctx, userID, after, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			if err := send(change); err != nil {
+				return err
+			}
+			after = change.ID
+		}
+
+		if len(changes) == batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+type cursorPayload struct {
+	UserID int   `json:"u"`
+	Date   int64 `json:"d"`
+	ID     int   `json:"i"`
+}
+
+type cursorSigner struct {
+	secret []byte
+}
+
+func (c cursorSigner) encode(userID int, cursor storage.Cursor) (string, error) {
+	payload, err := json.Marshal(cursorPayload{UserID: userID, Date: cursor.Date.UnixNano(), ID: cursor.ID})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded)), nil
+}
+
+func (c cursorSigner) decode(userID int, token string) (*storage.Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(encoded)) {
+		return nil, ErrInvalidCursor
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.UserID != userID {
+		return nil, ErrInvalidCursor
+	}
+
+	return &storage.Cursor{Date: time.Unix(0, payload.Date).UTC(), ID: payload.ID}, nil
+}
+
+func (c cursorSigner) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+var (
+	ErrRevisionRequired = errors.New("revision is required")
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+type HistoryStorage interface {
+	ListRevisions(ctx context.Context, id int, userID int) ([]storage.Revision, error)
+	RevertEvent(ctx context.Context, id int, userID int, revision int) (*storage.Event, error)
+}
+
+func (a *App) EventHistory(ctx context.Context, id int, userID int) (_ []storage.Revision, err error) {
+	ctx, span := tracing.Start(ctx, "App.EventHistory")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+	if id == 0 {
+		return nil, ErrEventIDRequired
+	}
+
+	return a.storage.ListRevisions(ctx, id, userID)
+}
+
+// RevertEvent restores the title, description, date and duration an event had at
+// the given revision. The revert itself is recorded as a new revision.
+func (a *App) RevertEvent(ctx context.Context, id int, userID int, revision int) (_ *storage.Event, err error) {
+	ctx, span := tracing.Start(ctx, "App.RevertEvent")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+	if id == 0 {
+		return nil, ErrEventIDRequired
+	}
+	if revision <= 0 {
+		return nil, ErrRevisionRequired
+	}
+
+	return a.storage.RevertEvent(ctx, id, userID, revision)
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const ClockLayout = "15:04"
+
+var (
+	ErrNotificationChannel  = errors.New("unknown notification channel")
+	ErrNotificationEmail    = errors.New("a valid email is required for the email channel")
+	ErrNotificationURL      = errors.New("an absolute http(s) url is required for the webhook channel")
+	ErrQuietHours           = errors.New("quiet hours must be HH:MM and set together")
+	ErrTimezone             = errors.New("unknown timezone")
+	ErrPreferencesNotFound  = errors.New("notification preferences not found")
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+var notificationChannels = map[storage.Channel]bool{
+	storage.ChannelEmail:   true,
+	storage.ChannelWebhook: true,
+	storage.ChannelLog:     true,
+}
+
+type NotificationStorage interface {
+	SetNotificationPreferences(ctx context.Context, preferences *storage.NotificationPreferences) error
+	GetNotificationPreferences(ctx context.Context, userID int) (*storage.NotificationPreferences, error)
+	ListNotifications(ctx context.Context, userID int, limit int) ([]storage.Notification, error)
+}
+
+// SetNotificationPreferences replaces the channels and quiet hours of a user.
+func (a *App) SetNotificationPreferences(
+	ctx context.Context, preferences *storage.NotificationPreferences,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "App.SetNotificationPreferences")
+	defer span.End(&err)
+
+	if preferences.UserID == 0 {
+		return ErrUserIDRequired
+	}
+
+	for _, channel := range preferences.Channels {
+		if !notificationChannels[channel] {
+			return ErrNotificationChannel
+		}
+	}
+
+	if preferences.Enabled(storage.ChannelEmail) {
+		address, err := mail.ParseAddress(preferences.Email)
+		if err != nil {
+			return ErrNotificationEmail
+		}
+		// a display name such as "Jane <jane@example.org>" is not a valid SMTP recipient
+		preferences.Email = address.Address
+	}
+
+	if preferences.Enabled(storage.ChannelWebhook) {
+		parsed, err := url.Parse(preferences.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ErrNotificationURL
+		}
+	}
+
+	if (preferences.QuietStart == "") != (preferences.QuietEnd == "") {
+		return ErrQuietHours
+	}
+	if preferences.QuietStart != "" {
+		_, startErr := time.Parse(ClockLayout, preferences.QuietStart)
+		_, endErr := time.Parse(ClockLayout, preferences.QuietEnd)
+		if startErr != nil || endErr != nil {
+			return ErrQuietHours
+		}
+	}
+
+	if _, err := time.LoadLocation(preferences.Timezone); err != nil {
+		return ErrTimezone
+	}
+
+	return a.storage.SetNotificationPreferences(ctx, preferences)
+}
+
+func (a *App) GetNotificationPreferences(
+	ctx context.Context, userID int,
+) (_ *storage.NotificationPreferences, err error) {
+	ctx, span := tracing.Start(ctx, "App.GetNotificationPreferences")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	return a.storage.GetNotificationPreferences(ctx, userID)
+}
+
+// ListNotifications returns the user's reminders newest first with their per-channel delivery status.
+func (a *App) ListNotifications(ctx context.Context, userID int, limit int) (_ []storage.Notification, err error) {
+	ctx, span := tracing.Start(ctx, "App.ListNotifications")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	if limit <= 0 {
+		limit = DefaultDeliveriesPageSize
+	}
+	if limit > MaxDeliveriesPageSize {
+		limit = MaxDeliveriesPageSize
+	}
+
+	return a.storage.ListNotifications(ctx, userID, limit)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const (
+	MaxReminders      = 10
+	MaxReminderOffset = 7 * 24 * time.Hour
+)
+
+var (
+	ErrReminderOffset    = errors.New("reminder offset must be positive and at most 168h")
+	ErrReminderDuplicate = errors.New("duplicate reminder")
+	ErrTooManyReminders  = errors.New("too many reminders")
+)
+
+type ReminderStorage interface {
+	SetReminders(ctx context.Context, eventID int, userID int, reminders []storage.Reminder) error
+	ListReminders(ctx context.Context, eventID int, userID int) ([]storage.Reminder, error)
+}
+
+// SetReminders replaces the reminders of an event; an event without reminders
+// is reminded once, the configured lead time before it starts.
+func (a *App) SetReminders(ctx context.Context, eventID int, userID int, reminders []storage.Reminder) (err error) {
+	ctx, span := tracing.Start(ctx, "App.SetReminders")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return ErrUserIDRequired
+	}
+	if eventID == 0 {
+		return ErrEventIDRequired
+	}
+	if len(reminders) > MaxReminders {
+		return ErrTooManyReminders
+	}
+
+	type reminderKey struct {
+		offset  time.Duration
+		channel storage.Channel
+	}
+	seen := make(map[reminderKey]bool, len(reminders))
+
+	for _, reminder := range reminders {
+		if reminder.Offset <= 0 || reminder.Offset > MaxReminderOffset {
+			return ErrReminderOffset
+		}
+		if reminder.Channel != "" && !notificationChannels[reminder.Channel] {
+			return ErrNotificationChannel
+		}
+
+		key := reminderKey{offset: reminder.Offset, channel: reminder.Channel}
+		if seen[key] {
+			return ErrReminderDuplicate
+		}
+		seen[key] = true
+	}
+
+	return a.storage.SetReminders(ctx, eventID, userID, reminders)
+}
+
+func (a *App) ListReminders(ctx context.Context, eventID int, userID int) (_ []storage.Reminder, err error) {
+	ctx, span := tracing.Start(ctx, "App.ListReminders")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+	if eventID == 0 {
+		return nil, ErrEventIDRequired
+	}
+
+	return a.storage.ListReminders(ctx, eventID, userID)
+}
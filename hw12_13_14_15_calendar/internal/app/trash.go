@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const (
+	defaultRestoreWindow = 30 * 24 * time.Hour
+	defaultPurgeInterval = time.Hour
+)
+
+var (
+	ErrEventNotInTrash      = errors.New("event is not in trash")
+	ErrRestoreWindowExpired = errors.New("restore window has expired")
+)
+
+type TrashStorage interface {
+	ListTrash(ctx context.Context, userID int) ([]storage.Event, error)
+	RestoreEvent(ctx context.Context, id int, userID int, deletedAfter time.Time) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error)
+}
+
+func (a *App) ListTrash(ctx context.Context, userID int) (_ []storage.Event, err error) {
+	ctx, span := tracing.Start(ctx, "App.ListTrash")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	return a.storage.ListTrash(ctx, userID)
+}
+
+func (a *App) RestoreEvent(ctx context.Context, id int, userID int) (err error) {
+	ctx, span := tracing.Start(ctx, "App.RestoreEvent")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return ErrUserIDRequired
+	}
+	if id == 0 {
+		return ErrEventIDRequired
+	}
+
+	return a.storage.RestoreEvent(ctx, id, userID, time.Now().Add(-a.restoreWindow()))
+}
+
+// PurgeTrash permanently removes events that stayed in trash longer than the restore window.
+func (a *App) PurgeTrash(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "App.PurgeTrash")
+	defer span.End(&err)
+
+	return a.storage.PurgeDeleted(ctx, time.Now().Add(-a.restoreWindow()))
+}
+
+func (a *App) RunTrashPurge(ctx context.Context) {
+	interval := a.trash.PurgeInterval
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := a.PurgeTrash(ctx)
+		if err != nil {
+			a.logger.Error("failed to purge trash: " + err.Error())
+		} else if purged > 0 {
+			a.logger.Info("purged deleted events", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) restoreWindow() time.Duration {
+	if a.trash.RestoreWindow <= 0 {
+		return defaultRestoreWindow
+	}
+	return a.trash.RestoreWindow
+}
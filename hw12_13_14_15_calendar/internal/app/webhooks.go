@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const (
+	webhookSecretSize         = 32
+	DefaultDeliveriesPageSize = 50
+	MaxDeliveriesPageSize     = 500
+)
+
+var (
+	ErrWebhookURLInvalid = errors.New("webhook url must be an absolute http(s) url")
+	ErrWebhookEventType  = errors.New("unknown webhook event type")
+	ErrWebhookIDRequired = errors.New("webhook id is required")
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrDeliveryNotFound  = errors.New("webhook delivery not found")
+)
+
+var webhookEventTypes = map[storage.ChangeType]bool{
+	storage.ChangeCreated:  true,
+	storage.ChangeUpdated:  true,
+	storage.ChangeDeleted:  true,
+	storage.ChangeRestored: true,
+	storage.ChangeStarting: true,
+}
+
+type WebhookStorage interface {
+	AddWebhook(ctx context.Context, webhook *storage.Webhook) error
+	ListWebhooks(ctx context.Context, userID int) ([]storage.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int, userID int) error
+	ListWebhookDeliveries(ctx context.Context, userID int, webhookID int, limit int) ([]storage.WebhookDelivery, error)
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]storage.WebhookDelivery, error)
+	PendingDeliveries(ctx context.Context) (int, error)
+}
+
+type QueueState struct {
+	Pending int
+	Due     []storage.WebhookDelivery
+}
+
+// CreateWebhook registers a subscription. When no secret is given a random one is
+// generated and returned in webhook.Secret so the caller can verify signatures.
+func (a *App) CreateWebhook(ctx context.Context, webhook *storage.Webhook) (err error) {
+	ctx, span := tracing.Start(ctx, "App.CreateWebhook")
+	defer span.End(&err)
+
+	if webhook.UserID == 0 {
+		return ErrUserIDRequired
+	}
+
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrWebhookURLInvalid
+	}
+
+	for _, eventType := range webhook.Events {
+		if !webhookEventTypes[eventType] {
+			return ErrWebhookEventType
+		}
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, webhookSecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	return a.storage.AddWebhook(ctx, webhook)
+}
+
+func (a *App) ListWebhooks(ctx context.Context, userID int) (_ []storage.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "App.ListWebhooks")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	return a.storage.ListWebhooks(ctx, userID)
+}
+
+func (a *App) DeleteWebhook(ctx context.Context, id int, userID int) (err error) {
+	ctx, span := tracing.Start(ctx, "App.DeleteWebhook")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return ErrUserIDRequired
+	}
+	if id == 0 {
+		return ErrWebhookIDRequired
+	}
+
+	return a.storage.DeleteWebhook(ctx, id, userID)
+}
+
+func (a *App) ListWebhookDeliveries(
+	ctx context.Context, userID int, webhookID int, limit int,
+) (_ []storage.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "App.ListWebhookDeliveries")
+	defer span.End(&err)
+
+	if userID == 0 {
+		return nil, ErrUserIDRequired
+	}
+
+	if limit <= 0 {
+		limit = DefaultDeliveriesPageSize
+	}
+	if limit > MaxDeliveriesPageSize {
+		limit = MaxDeliveriesPageSize
+	}
+
+	return a.storage.ListWebhookDeliveries(ctx, userID, webhookID, limit)
+}
+
+// QueueState reports how many webhook deliveries wait to be sent and which of them are due now.
+func (a *App) QueueState(ctx context.Context, limit int) (_ *QueueState, err error) {
+	ctx, span := tracing.Start(ctx, "App.QueueState")
+	defer span.End(&err)
+
+	if limit <= 0 {
+		limit = DefaultDeliveriesPageSize
+	}
+
+	pending, err := a.storage.PendingDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	due, err := a.storage.DueDeliveries(ctx, time.Now(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueState{Pending: pending, Due: due}, nil
+}
@@ -2,8 +2,9 @@ package config
 
 import (
 	"errors"
-	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,67 +13,239 @@ import (
 // Организация конфига в main принуждает нас сужать API компонентов, использовать
 // при их конструировании только необходимые параметры, а также уменьшает вероятность циклической зависимости.
 
+const PathEnv = "CONFIG_PATH"
+
 var (
 	ErrConfigNotFound   = errors.New("config not found")
 	ErrFailedReadConfig = errors.New("failed to read config")
+	ErrInvalidConfig    = errors.New("invalid config")
 )
 
+// Every setting can be overridden by the environment variable built from the
+// env-prefix tags of its sections and its env tag, e.g. LOGGER_LEVEL or
+// HTTP_RATE_LIMIT_BURST; `calendar config env` lists them all.
 type Config struct {
-	Logger  LoggerConf `yaml:"logger"`
-	Storage Storage    `yaml:"storage"`
-	DB      DBConf     `yaml:"db"`
-	HTTP    HTTPConf   `yaml:"http"`
-	Env     string     `yaml:"env"  env-default:"local"`
+	Logger        LoggerConf       `yaml:"logger" env-prefix:"LOGGER_"`
+	Storage       Storage          `yaml:"storage" env-prefix:"STORAGE_"`
+	DB            DBConf           `yaml:"db" env-prefix:"DB_"`
+	HTTP          HTTPConf         `yaml:"http" env-prefix:"HTTP_"`
+	App           AppConf          `yaml:"app"`
+	Webhooks      WebhookConf      `yaml:"webhooks" env-prefix:"WEBHOOKS_"`
+	Notifications NotificationConf `yaml:"notifications" env-prefix:"NOTIFICATIONS_"`
+	Leader        LeaderConf       `yaml:"leader" env-prefix:"LEADER_"`
+	Tracing       TracingConf      `yaml:"tracing" env-prefix:"TRACING_"`
+	Health        HealthConf       `yaml:"health" env-prefix:"HEALTH_"`
+	Shutdown      ShutdownConf     `yaml:"shutdown" env-prefix:"SHUTDOWN_"`
+	Env           string           `yaml:"env" env:"ENV" env-default:"local" env-description:"deployment environment name"`
 }
 
 type DBConf struct {
-	Host     string `yaml:"host" env-default:"localhost"`
-	Port     string `yaml:"port" env-default:"5432"`
-	Database string `yaml:"database" env-required:"true"`
-	Username string `yaml:"username" env-required:"true"`
-	Password string `yaml:"password" env-required:"true"`
+	Host         string `yaml:"host" env:"HOST" env-default:"localhost" env-description:"PostgreSQL host"`
+	Port         string `yaml:"port" env:"PORT" env-default:"5432" env-description:"PostgreSQL port"`
+	Database     string `yaml:"database" env:"DATABASE" env-description:"database name, required for SQL storage"`
+	Username     string `yaml:"username" env:"USERNAME" env-description:"database user, required for SQL storage"`
+	UsernameFile string `yaml:"usernameFile" env:"USERNAME_FILE" env-description:"file holding the database user"`
+	Password     string `yaml:"password" env:"PASSWORD" env-description:"database password"`
+	PasswordFile string `yaml:"passwordFile" env:"PASSWORD_FILE" env-description:"file holding the database password"`
+	SSLMode      string `yaml:"sslMode" env:"SSL_MODE" env-default:"prefer" env-description:"disable, allow, prefer, require, verify-ca or verify-full"` //nolint:lll
+	SSLRootCert  string `yaml:"sslRootCert" env:"SSL_ROOT_CERT" env-description:"CA certificate verifying the server"`
+	SSLCert      string `yaml:"sslCert" env:"SSL_CERT" env-description:"client certificate"`
+	SSLKey       string `yaml:"sslKey" env:"SSL_KEY" env-description:"client certificate key"`
+	MaxConns     int32  `yaml:"maxConns" env:"MAX_CONNS" env-default:"10" env-description:"size of the connection pool"`
 }
 
 type HTTPConf struct {
-	Host string `yaml:"host" env-default:"0.0.0.0"`
-	Port string `yaml:"port" env-default:"8888"`
+	Host           string        `yaml:"host" env:"HOST" env-default:"0.0.0.0" env-description:"address to listen on"`
+	Port           string        `yaml:"port" env:"PORT" env-default:"8888" env-description:"port to listen on"`
+	TrustedProxies []string      `yaml:"trustedProxies" env:"TRUSTED_PROXIES" env-description:"comma separated IPs or CIDRs allowed to set forwarding headers"` //nolint:lll
+	AdminToken     string        `yaml:"adminToken" env:"ADMIN_TOKEN" env-description:"bearer token for /admin endpoints, which are disabled when empty"`       //nolint:lll
+	AdminTokenFile string        `yaml:"adminTokenFile" env:"ADMIN_TOKEN_FILE" env-description:"file holding the admin token"`                                  //nolint:lll
+	RateLimit      RateLimitConf `yaml:"rateLimit" env-prefix:"RATE_LIMIT_"`
+	TLS            TLSConf       `yaml:"tls" env-prefix:"TLS_"`
+}
+
+// TLSConf turns on HTTPS, which also enables HTTP/2. The files are watched
+// and a changed certificate or client CA is picked up without a restart.
+//
+// TODO: TLS was also asked for on the gRPC server, which does not exist yet
+// (api/EventService.proto has no service). It should load its certificates
+// from this section, through the same reloader, once it is added.
+type TLSConf struct {
+	Enabled        bool          `yaml:"enabled" env:"ENABLED" env-description:"serve HTTPS and HTTP/2"`
+	CertFile       string        `yaml:"certFile" env:"CERT_FILE" env-description:"PEM certificate chain"`
+	KeyFile        string        `yaml:"keyFile" env:"KEY_FILE" env-description:"PEM private key"`
+	ClientAuth     string        `yaml:"clientAuth" env:"CLIENT_AUTH" env-default:"none" env-description:"client certificates: none, optional or require"`     //nolint:lll
+	ClientCAFile   string        `yaml:"clientCAFile" env:"CLIENT_CA_FILE" env-description:"PEM CA bundle verifying client certificates"`                      //nolint:lll
+	SelfSigned     bool          `yaml:"selfSigned" env:"SELF_SIGNED" env-description:"generate a self-signed certificate when the files are missing"`         //nolint:lll
+	ReloadInterval time.Duration `yaml:"reloadInterval" env:"RELOAD_INTERVAL" env-default:"30s" env-description:"how often the files are checked for changes"` //nolint:lll
+}
+
+type RateLimitConf struct {
+	Enabled bool                      `yaml:"enabled" env:"ENABLED" env-description:"enable per client and per user rate limiting"` //nolint:lll
+	Rate    float64                   `yaml:"rate" env:"RATE" env-default:"10" env-description:"requests per second"`
+	Burst   int                       `yaml:"burst" env:"BURST" env-default:"20" env-description:"requests allowed in a burst"` //nolint:lll
+	Routes  map[string]RouteLimitConf `yaml:"routes"`
+}
+
+type RouteLimitConf struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
 }
 
 type Storage struct {
-	Type string `yaml:"type" env-default:"MEMORY"`
+	Type string `yaml:"type" env:"TYPE" env-default:"MEMORY" env-description:"storage backend: MEMORY or SQL"`
+}
+
+type AppConf struct {
+	Pagination PaginationConf `yaml:"pagination" env-prefix:"PAGINATION_"`
+	ChangeFeed ChangeFeedConf `yaml:"changeFeed" env-prefix:"CHANGE_FEED_"`
+	Trash      TrashConf      `yaml:"trash" env-prefix:"TRASH_"`
+	Cache      CacheConf      `yaml:"cache" env-prefix:"CACHE_"`
+}
+
+// CacheConf sizes the per-process cache of event listings. Only writes through
+// this process invalidate a user's listings, so it is off by default: enable
+// it for a single replica, where TTL bounds how stale listings get after a
+// write through the admin tool.
+type CacheConf struct {
+	Enabled bool          `yaml:"enabled" env:"ENABLED" env-default:"false" env-description:"cache event listings, single replica only"` //nolint:lll
+	TTL     time.Duration `yaml:"ttl" env:"TTL" env-default:"30s" env-description:"how long a cached listing is served"`
+	Size    int           `yaml:"size" env:"SIZE" env-default:"1000" env-description:"listings kept in the cache"`
+}
+
+type TrashConf struct {
+	RestoreWindow time.Duration `yaml:"restoreWindow" env:"RESTORE_WINDOW" env-default:"720h" env-description:"how long deleted events can be restored"` //nolint:lll
+	PurgeInterval time.Duration `yaml:"purgeInterval" env:"PURGE_INTERVAL" env-default:"1h" env-description:"how often expired trash is purged"`         //nolint:lll
+}
+
+type ChangeFeedConf struct {
+	PollInterval time.Duration `yaml:"pollInterval" env:"POLL_INTERVAL" env-default:"1s" env-description:"change stream poll interval"` //nolint:lll
+	BatchSize    int           `yaml:"batchSize" env:"BATCH_SIZE" env-default:"100" env-description:"changes read per poll"`            //nolint:lll
+}
+
+type PaginationConf struct {
+	Secret      string `yaml:"secret" env:"SECRET" env-description:"key signing page cursors, random per process when empty"`
+	SecretFile  string `yaml:"secretFile" env:"SECRET_FILE" env-description:"file holding the cursor signing key"`                 //nolint:lll
+	DefaultSize int    `yaml:"defaultSize" env:"DEFAULT_SIZE" env-default:"50" env-description:"page size when none is requested"` //nolint:lll
+	MaxSize     int    `yaml:"maxSize" env:"MAX_SIZE" env-default:"500" env-description:"largest allowed page size"`
+}
+
+type WebhookConf struct {
+	Interval     time.Duration `yaml:"interval" env:"INTERVAL" env-default:"5s" env-description:"delivery worker tick interval"`            //nolint:lll
+	StartingLead time.Duration `yaml:"startingLead" env:"STARTING_LEAD" env-default:"15m" env-description:"how early event.starting fires"` //nolint:lll
+	Timeout      time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"5s" env-description:"delivery request timeout"`
+	MaxAttempts  int           `yaml:"maxAttempts" env:"MAX_ATTEMPTS" env-default:"8" env-description:"attempts before a delivery fails"` //nolint:lll
+	BackoffBase  time.Duration `yaml:"backoffBase" env:"BACKOFF_BASE" env-default:"10s" env-description:"first retry delay"`              //nolint:lll
+	BackoffMax   time.Duration `yaml:"backoffMax" env:"BACKOFF_MAX" env-default:"1h" env-description:"longest retry delay"`
+	BatchSize    int           `yaml:"batchSize" env:"BATCH_SIZE" env-default:"100" env-description:"deliveries sent per tick"`              //nolint:lll
+	Retention    time.Duration `yaml:"retention" env:"RETENTION" env-default:"168h" env-description:"how long finished deliveries are kept"` //nolint:lll
+	// AllowedNetworks lets deliveries reach loopback, private or link-local
+	// addresses, which are refused by default.
+	AllowedNetworks []string `yaml:"allowedNetworks" env:"ALLOWED_NETWORKS" env-description:"comma separated internal IPs or CIDRs deliveries may reach"` //nolint:lll
+}
+
+type NotificationConf struct {
+	Interval    time.Duration         `yaml:"interval" env:"INTERVAL" env-default:"10s" env-description:"notification sender tick interval"`           //nolint:lll
+	Lead        time.Duration         `yaml:"lead" env:"LEAD" env-default:"15m" env-description:"how early reminders are sent"`                        //nolint:lll
+	Timeout     time.Duration         `yaml:"timeout" env:"TIMEOUT" env-default:"5s" env-description:"per channel send timeout"`                       //nolint:lll
+	MaxAttempts int                   `yaml:"maxAttempts" env:"MAX_ATTEMPTS" env-default:"5" env-description:"attempts before a notification fails"`   //nolint:lll
+	BackoffBase time.Duration         `yaml:"backoffBase" env:"BACKOFF_BASE" env-default:"30s" env-description:"first retry delay"`                    //nolint:lll
+	BackoffMax  time.Duration         `yaml:"backoffMax" env:"BACKOFF_MAX" env-default:"1h" env-description:"longest retry delay"`                     //nolint:lll
+	BatchSize   int                   `yaml:"batchSize" env:"BATCH_SIZE" env-default:"100" env-description:"notifications sent per tick"`              //nolint:lll
+	Retention   time.Duration         `yaml:"retention" env:"RETENTION" env-default:"168h" env-description:"how long finished notifications are kept"` //nolint:lll
+	SMTP        SMTPConf              `yaml:"smtp" env-prefix:"SMTP_"`
+	Templates   NotificationTemplates `yaml:"templates" env-prefix:"TEMPLATE_"`
+	// AllowedNetworks lets the webhook channel reach loopback, private or
+	// link-local addresses, which are refused by default.
+	AllowedNetworks []string `yaml:"allowedNetworks" env:"ALLOWED_NETWORKS" env-description:"comma separated internal IPs or CIDRs webhook reminders may reach"` //nolint:lll
+}
+
+type SMTPConf struct {
+	Addr string `yaml:"addr" env:"ADDR" env-default:"localhost:1025" env-description:"SMTP server for the email channel"`     //nolint:lll
+	From string `yaml:"from" env:"FROM" env-default:"calendar@localhost" env-description:"sender address of reminder emails"` //nolint:lll
+}
+
+// NotificationTemplates are text/template sources rendered with the event
+// fields Title, Description, Start (formatted in the user's timezone),
+// Duration, EventID and UserID.
+type NotificationTemplates struct {
+	Subject string `yaml:"subject" env:"SUBJECT" env-default:"Reminder: {{.Title}}" env-description:"reminder subject template"`                            //nolint:lll
+	Body    string `yaml:"body" env:"BODY" env-default:"{{.Title}} starts at {{.Start}} and lasts {{.Duration}}." env-description:"reminder body template"` //nolint:lll
+}
+
+// LeaderConf controls the election that keeps a single replica running the
+// webhook worker and the notification sender.
+type LeaderConf struct {
+	Enabled       bool          `yaml:"enabled" env:"ENABLED" env-default:"true" env-description:"elect a single scheduler replica"`                  //nolint:lll
+	Name          string        `yaml:"name" env:"NAME" env-default:"calendar-scheduler" env-description:"lock name shared by the replicas"`          //nolint:lll
+	LockFile      string        `yaml:"lockFile" env:"LOCK_FILE" env-description:"lock file for memory storage, defaults to <tmp>/<name>.lock"`       //nolint:lll
+	RetryInterval time.Duration `yaml:"retryInterval" env:"RETRY_INTERVAL" env-default:"5s" env-description:"how often a standby tries the lock"`     //nolint:lll
+	CheckInterval time.Duration `yaml:"checkInterval" env:"CHECK_INTERVAL" env-default:"5s" env-description:"how often the leader confirms the lock"` //nolint:lll
+}
+
+type TracingConf struct {
+	Exporter string `yaml:"exporter" env:"EXPORTER" env-default:"none" env-description:"span exporter: none, stdout or file"` //nolint:lll
+	File     string `yaml:"file" env:"FILE" env-default:"traces.jsonl" env-description:"span file for the file exporter"`
+	Service  string `yaml:"service" env:"SERVICE" env-default:"calendar" env-description:"service name on spans"`
+}
+
+type HealthConf struct {
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"2s" env-description:"default readiness check timeout"`
+}
+
+type ShutdownConf struct {
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"10s" env-description:"deadline for draining requests and stopping workers"` //nolint:lll
 }
 
 type LoggerConf struct {
-	Level string `yaml:"level" env-default:"INFO"`
+	Level    string            `yaml:"level" env:"LEVEL" env-default:"INFO" env-description:"DEBUG, INFO, WARN or ERROR"`
+	Format   string            `yaml:"format" env:"FORMAT" env-default:"text" env-description:"text or json"`
+	Output   string            `yaml:"output" env:"OUTPUT" env-default:"stdout" env-description:"stdout, stderr or file"`
+	File     LogFileConf       `yaml:"file" env-prefix:"FILE_"`
+	Packages map[string]string `yaml:"packages" env:"PACKAGES" env-description:"per-package levels as name:LEVEL pairs"`
 }
 
-func NewConfig() (*Config, error) {
-	configPath := fetchConfigPath()
-	if configPath == "" {
-		return nil, ErrConfigNotFound
+type LogFileConf struct {
+	Path       string `yaml:"path" env:"PATH" env-default:"calendar.log" env-description:"log file path"`
+	MaxSize    int    `yaml:"maxSize" env:"MAX_SIZE" env-default:"100" env-description:"megabytes before rotation"`
+	MaxBackups int    `yaml:"maxBackups" env:"MAX_BACKUPS" env-default:"5" env-description:"rotated files to keep, 0 for none"` //nolint:lll
+}
+
+// Path returns the config file named by the -config flag, falling back to CONFIG_PATH.
+func Path(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
 	}
+	return os.Getenv(PathEnv)
+}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+// Load reads the YAML file, applies environment overrides and validates the result.
+func Load(path string) (*Config, error) {
+	if path == "" {
 		return nil, ErrConfigNotFound
 	}
 
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
+	}
+
 	var cfg Config
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		return nil, ErrFailedReadConfig
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedReadConfig, err)
 	}
-	return &cfg, nil
-}
-
-func fetchConfigPath() string {
-	var res string
 
-	flag.StringVar(&res, "config", "", "path to config file")
-	flag.Parse()
+	if err := cfg.readSecretFiles(); err != nil {
+		return nil, err
+	}
 
-	if res == "" {
-		res = os.Getenv("CONFIG_PATH")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return &cfg, nil
+}
 
-	return res
+// EnvDescription documents the environment variable of every setting.
+func EnvDescription() (string, error) {
+	return cleanenv.GetDescription(&Config{}, nil)
 }
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() Config {
+	return Config{
+		Logger:  LoggerConf{Level: "INFO", Format: "text", Output: "stdout"},
+		Storage: Storage{Type: StorageTypeMemory},
+		HTTP:    HTTPConf{Host: "0.0.0.0", Port: "8080", RateLimit: RateLimitConf{Enabled: true, Rate: 10, Burst: 20}},
+		App: AppConf{
+			Pagination: PaginationConf{DefaultSize: 50, MaxSize: 500},
+			ChangeFeed: ChangeFeedConf{PollInterval: time.Second, BatchSize: 100},
+			Trash:      TrashConf{RestoreWindow: time.Hour, PurgeInterval: time.Minute},
+			Cache:      CacheConf{Enabled: true, TTL: time.Second, Size: 10},
+		},
+		Tracing:  TracingConf{Exporter: "none", Service: "calendar"},
+		Health:   HealthConf{Timeout: time.Second},
+		Shutdown: ShutdownConf{Timeout: time.Second},
+		Leader: LeaderConf{
+			Enabled:       true,
+			Name:          "calendar-scheduler",
+			RetryInterval: time.Second,
+			CheckInterval: time.Second,
+		},
+		Webhooks: WebhookConf{
+			Interval:    time.Second,
+			Timeout:     time.Second,
+			MaxAttempts: 3,
+			BackoffBase: time.Second,
+			BackoffMax:  time.Minute,
+			BatchSize:   10,
+			Retention:   time.Hour,
+		},
+		Notifications: NotificationConf{
+			Interval:    time.Second,
+			Lead:        time.Minute,
+			Timeout:     time.Second,
+			MaxAttempts: 3,
+			BackoffBase: time.Second,
+			BackoffMax:  time.Minute,
+			BatchSize:   10,
+			Retention:   time.Hour,
+			SMTP:        SMTPConf{Addr: "localhost:1025", From: "calendar@localhost"},
+			Templates:   NotificationTemplates{Subject: "{{.Title}}", Body: "{{.Start}}"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	conf := validConfig()
+	require.NoError(t, conf.Validate())
+
+	conf.Logger.Level = "LOUD"
+	conf.Storage.Type = "CASSANDRA"
+	conf.HTTP.Port = "http"
+	conf.HTTP.TrustedProxies = []string{"10.0.0.0/33"}
+	conf.HTTP.RateLimit.Rate = -1
+	conf.App.Trash.PurgeInterval = 0
+	conf.App.Cache.Size = 0
+	conf.Webhooks.BackoffMax = time.Millisecond
+	conf.Webhooks.AllowedNetworks = []string{"localhost"}
+	conf.Notifications.SMTP.Addr = "localhost"
+	conf.Notifications.AllowedNetworks = []string{"10.0.0.1/"}
+	conf.Notifications.Templates.Body = "{{.Title"
+	conf.Leader.CheckInterval = 0
+	conf.HTTP.TLS = TLSConf{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "require"}
+
+	err := conf.Validate()
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	for _, setting := range []string{
+		"logger.level", "storage.type", "http.port", "http.trustedProxies",
+		"http.rateLimit.rate", "http.tls.clientCAFile", "http.tls.reloadInterval",
+		"app.trash.purgeInterval", "app.cache.size", "webhooks.backoffMax", "webhooks.allowedNetworks",
+		"notifications.smtp.addr", "notifications.allowedNetworks", "notifications.templates.body",
+		"leader.checkInterval",
+	} {
+		require.ErrorContains(t, err, setting)
+	}
+
+	conf = validConfig()
+	conf.Storage.Type = "sql"
+	conf.DB = DBConf{Host: "localhost", Port: "5432"}
+	err = conf.Validate()
+	require.ErrorContains(t, err, "db.database")
+	require.ErrorContains(t, err, "db.username")
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("storage:\n  type: MEMORY\nhttp:\n  port: \"9000\"\n"), 0o600))
+
+	t.Setenv("HTTP_PORT", "9100")
+	t.Setenv("PAGINATION_SECRET", "from-env")
+
+	conf, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "9100", conf.HTTP.Port)
+	require.Equal(t, "from-env", conf.App.Pagination.Secret)
+	require.Equal(t, 5*time.Second, conf.Webhooks.Interval)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.ErrorIs(t, err, ErrConfigNotFound)
+
+	require.NoError(t, os.WriteFile(path, []byte("storage: [\n"), 0o600))
+	_, err = Load(path)
+	require.ErrorIs(t, err, ErrFailedReadConfig)
+}
+
+func TestPrint(t *testing.T) {
+	conf := validConfig()
+	conf.DB.Password = "hunter2"
+	conf.App.Pagination.Secret = "cursor-key"
+	conf.HTTP.AdminToken = "admin-key"
+
+	var buf bytes.Buffer
+	require.NoError(t, conf.Masked().WriteYAML(&buf))
+
+	require.NotContains(t, buf.String(), "hunter2")
+	require.NotContains(t, buf.String(), "cursor-key")
+	require.NotContains(t, buf.String(), "admin-key")
+	require.Contains(t, buf.String(), "password: '******'")
+	require.Contains(t, buf.String(), "backoffMax: 1m0s")
+	require.Equal(t, "hunter2", conf.DB.Password, "masking works on a copy")
+}
+
+func TestDiff(t *testing.T) {
+	old := validConfig()
+	updated := validConfig()
+	updated.Logger.Level = "DEBUG"
+	updated.HTTP.RateLimit.Routes = map[string]RouteLimitConf{"/events": {Rate: 1, Burst: 2}}
+	updated.DB.Password = "new-password"
+
+	require.Equal(t, []Change{
+		{Path: "db.password", Old: masked, New: masked},
+		{Path: "http.rateLimit.routes", Old: "map[]", New: "map[/events:{1 2}]"},
+		{Path: "logger.level", Old: `"INFO"`, New: `"DEBUG"`},
+	}, Diff(&old, &updated))
+
+	require.Empty(t, Diff(&old, &old))
+}
+
+func TestSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(secret, []byte("from-file\n"), 0o600))
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("db:\n  passwordFile: "+secret+"\n"), 0o600))
+
+	conf, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "from-file", conf.DB.Password)
+
+	t.Setenv("DB_PASSWORD", "inline")
+	_, err = Load(path)
+	require.ErrorIs(t, err, ErrSecretConflict)
+
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	_, err = Load(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const masked = "******"
+
+// Change is a single setting that differs between two configs, named by its YAML path.
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	return c.Path + ": " + c.Old + " -> " + c.New
+}
+
+// Diff lists the settings changed from old to updated; secrets are masked.
+func Diff(old, updated *Config) []Change {
+	var changes []Change
+	diffValue("", reflect.ValueOf(*old), reflect.ValueOf(*updated), false, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffValue(path string, old, updated reflect.Value, secret bool, changes *[]Change) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			field := old.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+
+			diffValue(name, old.Field(i), updated.Field(i), isSecret(field.Name), changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(old.Interface(), updated.Interface()) {
+		return
+	}
+
+	change := Change{Path: path, Old: format(old), New: format(updated)}
+	if secret {
+		change.Old, change.New = masked, masked
+	}
+	*changes = append(*changes, change)
+}
+
+func format(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return fmt.Sprintf("%q", value.String())
+	}
+	return fmt.Sprintf("%v", value.Interface())
+}
+
+func isSecret(field string) bool {
+	return field == "Password" || field == "Secret" || field == "AdminToken"
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Masked returns a copy with every non-empty secret replaced by a placeholder.
+func (c Config) Masked() Config {
+	maskSecrets(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func maskSecrets(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+
+		switch {
+		case field.Kind() == reflect.Struct:
+			maskSecrets(field)
+		case isSecret(value.Type().Field(i).Name) && field.Kind() == reflect.String && field.String() != "":
+			field.SetString(masked)
+		}
+	}
+}
+
+// WriteYAML writes the config in file order with durations in their readable form.
+func (c Config) WriteYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(yamlNode(reflect.ValueOf(c))); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func yamlNode(value reflect.Value) *yaml.Node {
+	switch {
+	case value.Type() == durationType:
+		return scalar(time.Duration(value.Int()).String())
+	case value.Kind() == reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < value.NumField(); i++ {
+			name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("yaml"), ",")
+			node.Content = append(node.Content, scalar(name), yamlNode(value.Field(i)))
+		}
+		return node
+	case value.Kind() == reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range keys {
+			node.Content = append(node.Content, scalar(fmt.Sprint(key)), yamlNode(value.MapIndex(key)))
+		}
+		return node
+	case value.Kind() == reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < value.Len(); i++ {
+			node.Content = append(node.Content, yamlNode(value.Index(i)))
+		}
+		return node
+	default:
+		node := &yaml.Node{}
+		_ = node.Encode(value.Interface())
+		return node
+	}
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
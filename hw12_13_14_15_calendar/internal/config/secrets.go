@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrSecretConflict = errors.New("secret is set both inline and by file")
+
+// readSecretFiles fills secrets from their *File settings, Docker secrets
+// style: the whole file with trailing newlines trimmed.
+func (c *Config) readSecretFiles() error {
+	secrets := []struct {
+		path  string
+		file  string
+		value *string
+	}{
+		{path: "db.username", file: c.DB.UsernameFile, value: &c.DB.Username},
+		{path: "db.password", file: c.DB.PasswordFile, value: &c.DB.Password},
+		{path: "app.pagination.secret", file: c.App.Pagination.SecretFile, value: &c.App.Pagination.Secret},
+		{path: "http.adminToken", file: c.HTTP.AdminTokenFile, value: &c.HTTP.AdminToken},
+	}
+
+	var errs []error
+	for _, secret := range secrets {
+		if secret.file == "" {
+			continue
+		}
+		if *secret.value != "" {
+			errs = append(errs, fmt.Errorf("%s: %w", secret.path, ErrSecretConflict))
+			continue
+		}
+
+		data, err := os.ReadFile(secret.file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%sFile: %w", secret.path, err))
+			continue
+		}
+		*secret.value = strings.TrimRight(string(data), "\r\n")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	StorageTypeMemory = "MEMORY"
+	StorageTypeSQL    = "SQL"
+)
+
+var (
+	storageTypes    = []string{StorageTypeMemory, StorageTypeSQL}
+	logLevels       = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+	logFormats      = []string{"text", "json"}
+	logOutputs      = []string{"stdout", "stderr", "file"}
+	tracingExporter = []string{"none", "stdout", "file"}
+	sslModes        = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	clientAuthModes = []string{"none", "optional", "require"}
+)
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+func (v *validator) port(path, port string) {
+	number, err := strconv.Atoi(port)
+	v.check(err == nil && number > 0 && number <= 65535, "%s: invalid port %q", path, port)
+}
+
+// networks checks a list of IP addresses and CIDR ranges.
+func (v *validator) networks(path string, networks []string) {
+	for _, network := range networks {
+		_, _, err := net.ParseCIDR(network)
+		v.check(err == nil || net.ParseIP(network) != nil, "%s: invalid address %q", path, network)
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(oneOf(c.Storage.Type, storageTypes), "storage.type: unknown storage type %q", c.Storage.Type)
+	if strings.EqualFold(c.Storage.Type, StorageTypeSQL) {
+		v.check(c.DB.Host != "", "db.host: required for SQL storage")
+		v.port("db.port", c.DB.Port)
+		v.check(c.DB.Database != "", "db.database: required for SQL storage")
+		v.check(c.DB.Username != "", "db.username: required for SQL storage")
+		v.check(oneOf(c.DB.SSLMode, sslModes), "db.sslMode: unknown mode %q", c.DB.SSLMode)
+		v.check((c.DB.SSLCert == "") == (c.DB.SSLKey == ""), "db.sslCert: sslCert and sslKey must be set together")
+		v.check(c.DB.MaxConns > 0, "db.maxConns: must be positive")
+	}
+
+	c.validateLogger(v)
+	c.validateHTTP(v)
+
+	v.check(c.App.Pagination.DefaultSize > 0, "app.pagination.defaultSize: must be positive")
+	v.check(c.App.Pagination.MaxSize >= c.App.Pagination.DefaultSize,
+		"app.pagination.maxSize: must not be less than defaultSize")
+	v.check(c.App.ChangeFeed.PollInterval > 0, "app.changeFeed.pollInterval: must be positive")
+	v.check(c.App.ChangeFeed.BatchSize > 0, "app.changeFeed.batchSize: must be positive")
+	v.check(c.App.Trash.RestoreWindow > 0, "app.trash.restoreWindow: must be positive")
+	v.check(c.App.Trash.PurgeInterval > 0, "app.trash.purgeInterval: must be positive")
+	if c.App.Cache.Enabled {
+		v.check(c.App.Cache.TTL > 0, "app.cache.ttl: must be positive")
+		v.check(c.App.Cache.Size > 0, "app.cache.size: must be positive")
+	}
+
+	v.check(c.Webhooks.Interval > 0, "webhooks.interval: must be positive")
+	v.check(c.Webhooks.StartingLead >= 0, "webhooks.startingLead: must not be negative")
+	v.check(c.Webhooks.Timeout > 0, "webhooks.timeout: must be positive")
+	v.check(c.Webhooks.MaxAttempts > 0, "webhooks.maxAttempts: must be positive")
+	v.check(c.Webhooks.BackoffBase > 0, "webhooks.backoffBase: must be positive")
+	v.check(c.Webhooks.BackoffMax >= c.Webhooks.BackoffBase, "webhooks.backoffMax: must not be less than backoffBase")
+	v.check(c.Webhooks.BatchSize > 0, "webhooks.batchSize: must be positive")
+	v.check(c.Webhooks.Retention > c.Webhooks.StartingLead, "webhooks.retention: must exceed startingLead")
+	v.networks("webhooks.allowedNetworks", c.Webhooks.AllowedNetworks)
+
+	c.validateNotifications(v)
+
+	if c.Leader.Enabled {
+		v.check(c.Leader.Name != "", "leader.name: must not be empty")
+		v.check(c.Leader.RetryInterval > 0, "leader.retryInterval: must be positive")
+		v.check(c.Leader.CheckInterval > 0, "leader.checkInterval: must be positive")
+	}
+
+	v.check(oneOf(c.Tracing.Exporter, tracingExporter), "tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	v.check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file: required for the file exporter")
+	v.check(c.Tracing.Service != "", "tracing.service: must not be empty")
+
+	v.check(c.Health.Timeout > 0, "health.timeout: must be positive")
+	v.check(c.Shutdown.Timeout > 0, "shutdown.timeout: must be positive")
+
+	if len(v.errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(v.errs...))
+	}
+	return nil
+}
+
+func (c *Config) validateLogger(v *validator) {
+	v.check(oneOf(c.Logger.Level, logLevels), "logger.level: unknown level %q", c.Logger.Level)
+	v.check(oneOf(c.Logger.Format, logFormats), "logger.format: unknown format %q", c.Logger.Format)
+	v.check(oneOf(c.Logger.Output, logOutputs), "logger.output: unknown output %q", c.Logger.Output)
+	for name, level := range c.Logger.Packages {
+		v.check(oneOf(level, logLevels), "logger.packages.%s: unknown level %q", name, level)
+	}
+
+	if strings.EqualFold(c.Logger.Output, "file") {
+		v.check(c.Logger.File.Path != "", "logger.file.path: required for file output")
+		v.check(c.Logger.File.MaxSize > 0, "logger.file.maxSize: must be positive")
+		v.check(c.Logger.File.MaxBackups >= 0, "logger.file.maxBackups: must not be negative")
+	}
+}
+
+func (c *Config) validateHTTP(v *validator) {
+	v.port("http.port", c.HTTP.Port)
+	v.check(c.HTTP.Host == "" || net.ParseIP(c.HTTP.Host) != nil || validHostname(c.HTTP.Host),
+		"http.host: invalid host %q", c.HTTP.Host)
+
+	v.networks("http.trustedProxies", c.HTTP.TrustedProxies)
+
+	v.check(c.HTTP.RateLimit.Rate >= 0, "http.rateLimit.rate: must not be negative")
+	v.check(c.HTTP.RateLimit.Burst >= 0, "http.rateLimit.burst: must not be negative")
+	for route, limit := range c.HTTP.RateLimit.Routes {
+		v.check(strings.HasPrefix(route, "/"), "http.rateLimit.routes.%s: route must start with /", route)
+		v.check(limit.Rate >= 0, "http.rateLimit.routes.%s.rate: must not be negative", route)
+		v.check(limit.Burst >= 0, "http.rateLimit.routes.%s.burst: must not be negative", route)
+	}
+
+	if tlsConf := c.HTTP.TLS; tlsConf.Enabled {
+		v.check(tlsConf.CertFile != "", "http.tls.certFile: required for TLS")
+		v.check(tlsConf.KeyFile != "", "http.tls.keyFile: required for TLS")
+		v.check(oneOf(tlsConf.ClientAuth, clientAuthModes), "http.tls.clientAuth: unknown mode %q", tlsConf.ClientAuth)
+		v.check(strings.EqualFold(tlsConf.ClientAuth, "none") || tlsConf.ClientCAFile != "",
+			"http.tls.clientCAFile: required to verify client certificates")
+		v.check(tlsConf.ReloadInterval > 0, "http.tls.reloadInterval: must be positive")
+	}
+}
+
+func (c *Config) validateNotifications(v *validator) {
+	conf := c.Notifications
+	v.check(conf.Interval > 0, "notifications.interval: must be positive")
+	v.check(conf.Lead >= 0, "notifications.lead: must not be negative")
+	v.check(conf.Timeout > 0, "notifications.timeout: must be positive")
+	v.check(conf.MaxAttempts > 0, "notifications.maxAttempts: must be positive")
+	v.check(conf.BackoffBase > 0, "notifications.backoffBase: must be positive")
+	v.check(conf.BackoffMax >= conf.BackoffBase, "notifications.backoffMax: must not be less than backoffBase")
+	v.check(conf.BatchSize > 0, "notifications.batchSize: must be positive")
+	v.check(conf.Retention > 0, "notifications.retention: must be positive")
+	v.networks("notifications.allowedNetworks", conf.AllowedNetworks)
+
+	host, port, err := net.SplitHostPort(conf.SMTP.Addr)
+	v.check(err == nil && host != "", "notifications.smtp.addr: must be host:port, got %q", conf.SMTP.Addr)
+	if err == nil {
+		v.port("notifications.smtp.addr", port)
+	}
+	_, err = mail.ParseAddress(conf.SMTP.From)
+	v.check(err == nil, "notifications.smtp.from: invalid address %q", conf.SMTP.From)
+
+	_, err = template.New("subject").Parse(conf.Templates.Subject)
+	v.check(err == nil, "notifications.templates.subject: %v", err)
+	_, err = template.New("body").Parse(conf.Templates.Body)
+	v.check(err == nil, "notifications.templates.body: %v", err)
+}
+
+func validHostname(host string) bool {
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+		for _, c := range label {
+			if !(c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	t.Run("all checks pass", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("storage", func(context.Context) error { return nil }, 0)
+
+		report := checker.Ready(context.Background())
+		require.Equal(t, StatusOK, report.Status)
+		require.Equal(t, StatusOK, report.Checks["storage"].Status)
+	})
+
+	t.Run("failing check fails readiness", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("storage", func(context.Context) error { return nil }, 0)
+		checker.Add("migrations", func(context.Context) error { return errors.New("pending") }, 0)
+
+		report := checker.Ready(context.Background())
+		require.Equal(t, StatusFail, report.Status)
+		require.Equal(t, StatusOK, report.Checks["storage"].Status)
+		require.Equal(t, "pending", report.Checks["migrations"].Error)
+	})
+
+	t.Run("slow check times out", func(t *testing.T) {
+		checker := New(time.Second)
+		checker.Add("queue", func(ctx context.Context) error {
+			<-time.After(time.Minute)
+			return nil
+		}, 20*time.Millisecond)
+
+		started := time.Now()
+		report := checker.Ready(context.Background())
+		require.Less(t, time.Since(started), time.Second)
+		require.Equal(t, StatusFail, report.Status)
+		require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["queue"].Error)
+	})
+
+	t.Run("liveness has no checks", func(t *testing.T) {
+		checker := New(0)
+		checker.Add("storage", func(context.Context) error { return errors.New("down") }, 0)
+
+		require.Equal(t, StatusOK, checker.Live().Status)
+	})
+}
@@ -0,0 +1,122 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+)
+
+var ErrNotHeld = errors.New("leader lock is not held")
+
+type Logger interface {
+	Info(msg string, attrs ...any)
+	Error(msg string, attrs ...any)
+	Debug(msg string, attrs ...any)
+	Warn(msg string, attrs ...any)
+}
+
+type Metrics interface {
+	SetLeader(name string, leader bool)
+}
+
+// Lock is a mutex shared by every replica. It is only used from the
+// elector's goroutine, so implementations need no locking of their own.
+type Lock interface {
+	TryLock(ctx context.Context) (bool, error)
+	// Check reports an error once the lock may have passed to another replica.
+	Check(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// Elector runs tasks on exactly one replica at a time. Standbys retry the lock
+// every RetryInterval and take over once the leader releases it or dies.
+type Elector struct {
+	logger  Logger
+	lock    Lock
+	conf    config.LeaderConf
+	metrics Metrics
+}
+
+func New(logger Logger, lock Lock, conf config.LeaderConf, metrics Metrics) *Elector {
+	return &Elector{logger: logger, lock: lock, conf: conf, metrics: metrics}
+}
+
+// Run blocks until ctx is done. While this replica leads, every task runs with
+// a context that is canceled as soon as leadership is lost; Run waits for the
+// tasks to return before releasing the lock.
+func (e *Elector) Run(ctx context.Context, tasks ...func(ctx context.Context)) {
+	if !e.conf.Enabled {
+		e.runTasks(ctx, tasks)
+		return
+	}
+
+	for {
+		acquired, err := e.lock.TryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Warn("leader election failed", "name", e.conf.Name, "error", err.Error())
+		}
+		if acquired {
+			e.lead(ctx, tasks)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.conf.RetryInterval):
+		}
+	}
+}
+
+func (e *Elector) lead(ctx context.Context, tasks []func(ctx context.Context)) {
+	e.logger.Info("acquired leadership", "name", e.conf.Name)
+	e.metrics.SetLeader(e.conf.Name, true)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.runTasks(leaderCtx, tasks)
+	}()
+
+	ticker := time.NewTicker(e.conf.CheckInterval)
+	defer ticker.Stop()
+
+	for held := true; held; {
+		select {
+		case <-ctx.Done():
+			held = false
+		case <-ticker.C:
+			if err := e.lock.Check(ctx); err != nil {
+				e.logger.Warn("lost leadership", "name", e.conf.Name, "error", err.Error())
+				held = false
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), e.conf.CheckInterval)
+	defer cancelUnlock()
+	if err := e.lock.Unlock(unlockCtx); err != nil {
+		e.logger.Error("failed to release leader lock", "name", e.conf.Name, "error", err.Error())
+	}
+
+	e.metrics.SetLeader(e.conf.Name, false)
+	e.logger.Info("stepped down", "name", e.conf.Name)
+}
+
+func (e *Elector) runTasks(ctx context.Context, tasks []func(ctx context.Context)) {
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task func(ctx context.Context)) {
+			defer wg.Done()
+			task(ctx)
+		}(task)
+	}
+	wg.Wait()
+}
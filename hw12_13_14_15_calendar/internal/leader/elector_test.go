@@ -0,0 +1,170 @@
+//go:build unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsStub struct {
+	mu      sync.Mutex
+	changes []bool
+}
+
+func (m *metricsStub) SetLeader(_ string, leader bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changes = append(m.changes, leader)
+}
+
+func (m *metricsStub) recorded() []bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]bool(nil), m.changes...)
+}
+
+// lostLock grants the lock but fails the first check, like a dropped session.
+type lostLock struct {
+	mu      sync.Mutex
+	checked bool
+}
+
+func (l *lostLock) TryLock(context.Context) (bool, error) { return true, nil }
+
+func (l *lostLock) Check(context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.checked {
+		return nil
+	}
+	l.checked = true
+	return errors.New("connection reset")
+}
+
+func (l *lostLock) Unlock(context.Context) error { return nil }
+
+func TestElector(t *testing.T) {
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	conf := config.LeaderConf{
+		Enabled:       true,
+		Name:          "scheduler",
+		RetryInterval: 10 * time.Millisecond,
+		CheckInterval: 10 * time.Millisecond,
+	}
+
+	// run starts an elector whose task reports on started until it is stopped.
+	run := func(ctx context.Context, lock Lock, metrics Metrics, started chan<- string, name string) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			New(logg, lock, conf, metrics).Run(ctx, func(ctx context.Context) {
+				started <- name
+				<-ctx.Done()
+			})
+		}()
+		return done
+	}
+
+	t.Run("Failover", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "scheduler.lock")
+		started := make(chan string, 2)
+
+		firstCtx, stopFirst := context.WithCancel(context.Background())
+		firstMetrics := &metricsStub{}
+		firstDone := run(firstCtx, NewFileLock(path), firstMetrics, started, "first")
+		require.Equal(t, "first", <-started)
+
+		secondCtx, stopSecond := context.WithCancel(context.Background())
+		defer stopSecond()
+		secondDone := run(secondCtx, NewFileLock(path), &metricsStub{}, started, "second")
+
+		select {
+		case name := <-started:
+			t.Fatalf("%s started while first leads", name)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		stopFirst()
+		<-firstDone
+		require.Equal(t, []bool{true, false}, firstMetrics.recorded())
+
+		select {
+		case name := <-started:
+			require.Equal(t, "second", name)
+		case <-time.After(time.Second):
+			t.Fatal("standby did not take over")
+		}
+
+		stopSecond()
+		<-secondDone
+	})
+
+	t.Run("Lost Leadership Stops Tasks", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan string, 2)
+		metrics := &metricsStub{}
+		done := run(ctx, &lostLock{}, metrics, started, "leader")
+
+		require.Equal(t, "leader", <-started)
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("tasks did not restart after leadership was regained")
+		}
+
+		cancel()
+		<-done
+		require.Equal(t, []bool{true, false, true, false}, metrics.recorded())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		conf := conf
+		conf.Enabled = false
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ran := make(chan struct{})
+		go New(logg, nil, conf, &metricsStub{}).Run(ctx, func(context.Context) { close(ran) })
+
+		<-ran
+		cancel()
+	})
+}
+
+func TestFileLock(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "scheduler.lock")
+
+	lock := NewFileLock(path)
+	acquired, err := lock.TryLock(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.NoError(t, lock.Check(ctx))
+
+	other := NewFileLock(path)
+	acquired, err = other.TryLock(ctx)
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	require.NoError(t, lock.Unlock(ctx))
+	require.ErrorIs(t, lock.Check(ctx), ErrNotHeld)
+
+	acquired, err = other.TryLock(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	require.NoError(t, os.Remove(path))
+	require.ErrorIs(t, other.Check(ctx), os.ErrNotExist, "a removed lock file can be locked by anyone")
+	require.NoError(t, other.Unlock(ctx))
+}
@@ -0,0 +1,28 @@
+//go:build !unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrFileLockUnsupported = errors.New("file lock is not supported on this platform")
+
+type FileLock struct{}
+
+func NewFileLock(string) *FileLock {
+	return &FileLock{}
+}
+
+func (l *FileLock) TryLock(context.Context) (bool, error) {
+	return false, ErrFileLockUnsupported
+}
+
+func (l *FileLock) Check(context.Context) error {
+	return ErrNotHeld
+}
+
+func (l *FileLock) Unlock(context.Context) error {
+	return nil
+}
@@ -0,0 +1,87 @@
+//go:build unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// FileLock elects a leader among processes sharing a host through flock(2).
+// The kernel drops the lock when the holder exits, however it dies.
+type FileLock struct {
+	path string
+	file *os.File
+}
+
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+func (l *FileLock) TryLock(context.Context) (bool, error) {
+	if l.file != nil {
+		return true, nil
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return false, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		file.Close()
+		return false, nil
+	}
+	if err != nil {
+		file.Close()
+		return false, fmt.Errorf("lock %s: %w", l.path, err)
+	}
+
+	// the pid is informational, it tells operators which process leads
+	if err := file.Truncate(0); err == nil {
+		_, _ = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	l.file = file
+	return true, nil
+}
+
+// Check fails when the lock file was removed or replaced, since another
+// process could then lock the new file.
+func (l *FileLock) Check(context.Context) error {
+	if l.file == nil {
+		return ErrNotHeld
+	}
+
+	held, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	current, err := os.Stat(l.path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(held, current) {
+		return fmt.Errorf("%w: %s was replaced", ErrNotHeld, l.path)
+	}
+	return nil
+}
+
+func (l *FileLock) Unlock(context.Context) error {
+	if l.file == nil {
+		return nil
+	}
+
+	file := l.file
+	l.file = nil
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
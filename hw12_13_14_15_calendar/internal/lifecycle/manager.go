@@ -0,0 +1,136 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrStopTimeout = errors.New("component did not stop before the shutdown deadline")
+
+type Logger interface {
+	Info(msg string, attrs ...any)
+	Error(msg string, attrs ...any)
+	Debug(msg string, attrs ...any)
+	Warn(msg string, attrs ...any)
+}
+
+// Component is one part of the process. Every func is optional: Start runs
+// synchronously in start order, Run keeps running in the background until its
+// context is canceled, and Stop drains the component before that cancellation.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Run   func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Background wraps a loop that runs until its context is canceled.
+func Background(name string, run func(ctx context.Context)) Component {
+	return Component{Name: name, Run: func(ctx context.Context) error {
+		run(ctx)
+		return nil
+	}}
+}
+
+// Manager starts components in the order they were added and stops them in
+// reverse, so later components may depend on earlier ones until they stop.
+type Manager struct {
+	logger     Logger
+	timeout    time.Duration
+	components []Component
+}
+
+type running struct {
+	Component
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(logger Logger, timeout time.Duration) *Manager {
+	return &Manager{logger: logger, timeout: timeout}
+}
+
+func (m *Manager) Add(components ...Component) {
+	m.components = append(m.components, components...)
+}
+
+// Run starts every component and blocks until ctx is done or a component
+// fails, then stops the started ones within the shutdown timeout. The
+// returned error joins the failure that ended the run with every stop error.
+func (m *Manager) Run(ctx context.Context) error {
+	failures := make(chan error, len(m.components))
+	started := make([]*running, 0, len(m.components))
+
+	var failure error
+	for _, component := range m.components {
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				failure = fmt.Errorf("start %s: %w", component.Name, err)
+				break
+			}
+		}
+
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		current := &running{Component: component, cancel: cancel, done: make(chan struct{})}
+		started = append(started, current)
+
+		go func() {
+			defer close(current.done)
+			if current.Run == nil {
+				<-runCtx.Done()
+				return
+			}
+			if err := current.Run(runCtx); err != nil {
+				failures <- fmt.Errorf("%s: %w", current.Name, err)
+			}
+		}()
+		m.logger.Debug("component started", "component", component.Name)
+	}
+
+	if failure == nil {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("shutting down")
+		case failure = <-failures:
+			m.logger.Error("component failed, shutting down: " + failure.Error())
+		}
+	}
+
+	errs := []error{failure}
+	errs = append(errs, m.stop(started)...)
+	for {
+		select {
+		case err := <-failures:
+			errs = append(errs, err)
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+func (m *Manager) stop(started []*running) []error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		component := started[i]
+
+		if component.Stop != nil {
+			if err := component.Stop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("stop %s: %w", component.Name, err))
+			}
+		}
+		component.cancel()
+
+		select {
+		case <-component.done:
+			m.logger.Debug("component stopped", "component", component.Name)
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("stop %s: %w", component.Name, ErrStopTimeout))
+		}
+	}
+	return errs
+}
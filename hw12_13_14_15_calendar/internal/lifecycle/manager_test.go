@@ -0,0 +1,122 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type journal struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (j *journal) add(entry string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, entry)
+}
+
+func (j *journal) list() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]string(nil), j.entries...)
+}
+
+func TestManager(t *testing.T) {
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	// tracked records every step of a component and keeps it running until canceled.
+	tracked := func(j *journal, name string) Component {
+		return Component{
+			Name: name,
+			Start: func(context.Context) error {
+				j.add("start " + name)
+				return nil
+			},
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				j.add("done " + name)
+				return nil
+			},
+			Stop: func(context.Context) error {
+				j.add("stop " + name)
+				return nil
+			},
+		}
+	}
+
+	t.Run("Stops In Reverse Order", func(t *testing.T) {
+		j := &journal{}
+		manager := New(logg, time.Second)
+		manager.Add(tracked(j, "storage"), tracked(j, "http"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, manager.Run(ctx))
+
+		require.Equal(t, []string{
+			"start storage", "start http",
+			"stop http", "done http",
+			"stop storage", "done storage",
+		}, j.list())
+	})
+
+	t.Run("Failed Component Stops The Rest", func(t *testing.T) {
+		j := &journal{}
+		boom := errors.New("address in use")
+
+		manager := New(logg, time.Second)
+		manager.Add(tracked(j, "storage"), Component{
+			Name: "http",
+			Run:  func(context.Context) error { return boom },
+		})
+
+		err := manager.Run(context.Background())
+		require.ErrorIs(t, err, boom)
+		require.ErrorContains(t, err, "http: ")
+		require.Equal(t, []string{"start storage", "stop storage", "done storage"}, j.list())
+	})
+
+	t.Run("Failed Start", func(t *testing.T) {
+		j := &journal{}
+		boom := errors.New("connection refused")
+
+		failing := tracked(j, "http")
+		failing.Start = func(context.Context) error { return boom }
+
+		manager := New(logg, time.Second)
+		manager.Add(tracked(j, "storage"), failing, tracked(j, "never"))
+
+		err := manager.Run(context.Background())
+		require.ErrorIs(t, err, boom)
+		require.Equal(t, []string{"start storage", "stop storage", "done storage"}, j.list())
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		closeErr := errors.New("close failed")
+		manager := New(logg, 20*time.Millisecond)
+		manager.Add(
+			Component{Name: "storage", Stop: func(context.Context) error { return closeErr }},
+			Background("stuck", func(context.Context) { <-release }),
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := manager.Run(ctx)
+		require.ErrorIs(t, err, ErrStopTimeout)
+		require.ErrorContains(t, err, "stop stuck")
+		require.ErrorIs(t, err, closeErr, "later components are still stopped")
+	})
+}
@@ -1,31 +1,248 @@
 package logger
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+
+	megabyte = 1 << 20
+)
+
+var (
+	ErrUnknownLevel  = errors.New("unknown log level")
+	ErrUnknownFormat = errors.New("unknown log format")
+	ErrUnknownOutput = errors.New("unknown log output")
 )
 
 type Logger struct {
 	logger *slog.Logger
+	base   slog.Handler
+	levels *levels
+	output io.Writer
+}
+
+// levels holds the root level and per-package overrides; both can be changed at runtime.
+type levels struct {
+	mu       sync.RWMutex
+	root     slog.LevelVar
+	packages map[string]*slog.LevelVar
 }
 
-func New(level string) Logger {
-	var slogLevel slog.Level
-	switch level {
+// packageLevel resolves to the package override when one is set, otherwise to the root level.
+type packageLevel struct {
+	levels *levels
+	name   string
+}
+
+func (l packageLevel) Level() slog.Level {
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+
+	if level, ok := l.levels.packages[l.name]; ok {
+		return level.Level()
+	}
+	return l.levels.root.Level()
+}
+
+func New(conf config.LoggerConf) (Logger, error) {
+	var output io.Writer
+
+	switch strings.ToLower(conf.Output) {
+	case "", OutputStdout:
+		output = os.Stdout
+	case OutputStderr:
+		output = os.Stderr
+	case OutputFile:
+		file, err := newRotatingFile(conf.File.Path, int64(conf.File.MaxSize)*megabyte, conf.File.MaxBackups)
+		if err != nil {
+			return Logger{}, err
+		}
+		output = file
+	default:
+		return Logger{}, fmt.Errorf("%w: %q", ErrUnknownOutput, conf.Output)
+	}
+
+	logg, err := newLogger(output, conf)
+	if err != nil {
+		if closer, ok := output.(io.Closer); ok && output != os.Stdout && output != os.Stderr {
+			_ = closer.Close()
+		}
+		return Logger{}, err
+	}
+
+	return logg, nil
+}
+
+func newLogger(w io.Writer, conf config.LoggerConf) (Logger, error) {
+	// Filtering happens in contextHandler so that levels can change at runtime.
+	options := &slog.HandlerOptions{Level: slog.Level(-8)}
+
+	var base slog.Handler
+	switch strings.ToLower(conf.Format) {
+	case "", FormatText:
+		base = slog.NewTextHandler(w, options)
+	case FormatJSON:
+		base = slog.NewJSONHandler(w, options)
+	default:
+		return Logger{}, fmt.Errorf("%w: %q", ErrUnknownFormat, conf.Format)
+	}
+
+	root, err := ParseLevel(conf.Level)
+	if err != nil {
+		return Logger{}, err
+	}
+
+	lvls := &levels{packages: make(map[string]*slog.LevelVar, len(conf.Packages))}
+	lvls.root.Set(root)
+	for name, raw := range conf.Packages {
+		level, err := ParseLevel(raw)
+		if err != nil {
+			return Logger{}, fmt.Errorf("package %s: %w", name, err)
+		}
+
+		lvls.packages[name] = &slog.LevelVar{}
+		lvls.packages[name].Set(level)
+	}
+
+	return Logger{
+		logger: slog.New(contextHandler{Handler: base, level: &lvls.root}),
+		base:   base,
+		levels: lvls,
+		output: w,
+	}, nil
+}
+
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToUpper(level) {
 	case "INFO":
-		slogLevel = slog.LevelInfo
+		return slog.LevelInfo, nil
 	case "WARN":
-		slogLevel = slog.LevelWarn
+		return slog.LevelWarn, nil
 	case "DEBUG":
-		slogLevel = slog.LevelDebug
+		return slog.LevelDebug, nil
 	case "ERROR":
-		slogLevel = slog.LevelError
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownLevel, level)
+	}
+}
+
+// Named returns a logger for a package; its lines carry the package name and
+// obey that package's level override.
+func (l Logger) Named(name string) Logger {
+	return Logger{
+		logger: slog.New(contextHandler{
+			Handler: l.base.WithAttrs([]slog.Attr{slog.String("package", name)}),
+			level:   packageLevel{levels: l.levels, name: name},
+		}),
+		base:   l.base,
+		levels: l.levels,
+		output: l.output,
+	}
+}
+
+// Levels reports the root level under the empty name and every package override.
+func (l Logger) Levels() map[string]string {
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+
+	result := map[string]string{"": l.levels.root.Level().String()}
+	for name, level := range l.levels.packages {
+		result[name] = level.Level().String()
+	}
+	return result
+}
+
+// SetLevel changes the root level when name is empty, otherwise the package override.
+func (l Logger) SetLevel(name string, level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		l.levels.root.Set(parsed)
+		return nil
 	}
 
-	log := slog.New(
-		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slogLevel}))
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
 
-	return Logger{logger: log}
+	if _, ok := l.levels.packages[name]; !ok {
+		l.levels.packages[name] = &slog.LevelVar{}
+	}
+	l.levels.packages[name].Set(parsed)
+	return nil
+}
+
+// ApplyLevels replaces the root level and all package overrides; nothing
+// changes when any of the levels is unknown.
+func (l Logger) ApplyLevels(root string, packages map[string]string) error {
+	apply, err := l.PrepareLevels(root, packages)
+	if err != nil {
+		return err
+	}
+
+	apply()
+	return nil
+}
+
+// PrepareLevels parses the levels for ApplyLevels and returns the function
+// that puts them in place, so a caller can check other settings in between.
+func (l Logger) PrepareLevels(root string, packages map[string]string) (func(), error) {
+	rootLevel, err := ParseLevel(root)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]*slog.LevelVar, len(packages))
+	for name, raw := range packages {
+		level, err := ParseLevel(raw)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", name, err)
+		}
+
+		overrides[name] = &slog.LevelVar{}
+		overrides[name].Set(level)
+	}
+
+	return func() {
+		l.levels.mu.Lock()
+		defer l.levels.mu.Unlock()
+
+		l.levels.root.Set(rootLevel)
+		l.levels.packages = overrides
+	}, nil
+}
+
+// ResetLevel drops a package override so the package follows the root level again.
+func (l Logger) ResetLevel(name string) {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+	delete(l.levels.packages, name)
+}
+
+func (l Logger) Close() error {
+	if closer, ok := l.output.(*rotatingFile); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 func (l Logger) Info(msg string, attrs ...any) {
@@ -43,3 +260,19 @@ func (l Logger) Debug(msg string, attrs ...any) {
 func (l Logger) Warn(msg string, attrs ...any) {
 	l.logger.Warn(msg, attrs...)
 }
+
+func (l Logger) InfoContext(ctx context.Context, msg string, attrs ...any) {
+	l.logger.InfoContext(ctx, msg, attrs...)
+}
+
+func (l Logger) ErrorContext(ctx context.Context, msg string, attrs ...any) {
+	l.logger.ErrorContext(ctx, msg, attrs...)
+}
+
+func (l Logger) DebugContext(ctx context.Context, msg string, attrs ...any) {
+	l.logger.DebugContext(ctx, msg, attrs...)
+}
+
+func (l Logger) WarnContext(ctx context.Context, msg string, attrs ...any) {
+	l.logger.WarnContext(ctx, msg, attrs...)
+}
@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDAttached(t *testing.T) {
+	var buf bytes.Buffer
+	logg, err := newLogger(&buf, config.LoggerConf{Level: "DEBUG"})
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logg.InfoContext(ctx, "handled")
+	require.Contains(t, buf.String(), "msg=handled request_id=req-1")
+
+	buf.Reset()
+	logg.Info("background")
+	require.NotContains(t, buf.String(), "request_id")
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logg, err := newLogger(&buf, config.LoggerConf{Level: "WARN"})
+	require.NoError(t, err)
+
+	logg.Info("skipped")
+	require.Empty(t, buf.String())
+
+	logg.Warn("kept")
+	require.Contains(t, buf.String(), "msg=kept")
+}
+
+func TestUnknownSettings(t *testing.T) {
+	_, err := newLogger(&bytes.Buffer{}, config.LoggerConf{Level: "VERBOSE"})
+	require.ErrorIs(t, err, ErrUnknownLevel)
+
+	_, err = newLogger(&bytes.Buffer{}, config.LoggerConf{Level: "INFO", Format: "xml"})
+	require.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = newLogger(&bytes.Buffer{}, config.LoggerConf{Level: "INFO", Packages: map[string]string{"http": "LOUD"}})
+	require.ErrorIs(t, err, ErrUnknownLevel)
+
+	_, err = New(config.LoggerConf{Level: "INFO", Output: "syslog"})
+	require.ErrorIs(t, err, ErrUnknownOutput)
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logg, err := newLogger(&buf, config.LoggerConf{Level: "INFO", Format: "json"})
+	require.NoError(t, err)
+
+	logg.Named("http").InfoContext(WithRequestID(context.Background(), "req-1"), "handled", "status", 200)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "handled", line["msg"])
+	require.Equal(t, "http", line["package"])
+	require.Equal(t, "req-1", line["request_id"])
+	require.Equal(t, float64(200), line["status"])
+}
+
+func TestPackageLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logg, err := newLogger(&buf, config.LoggerConf{Level: "WARN", Packages: map[string]string{"webhook": "DEBUG"}})
+	require.NoError(t, err)
+
+	webhook, http := logg.Named("webhook"), logg.Named("http")
+
+	webhook.Debug("webhook debug")
+	http.Info("http info")
+	require.Contains(t, buf.String(), "webhook debug")
+	require.NotContains(t, buf.String(), "http info")
+
+	require.NoError(t, logg.SetLevel("http", "info"))
+	http.Info("http info")
+	require.Contains(t, buf.String(), "http info")
+
+	require.NoError(t, logg.SetLevel("", "ERROR"))
+	logg.ResetLevel("webhook")
+	webhook.Warn("webhook warn")
+	require.NotContains(t, buf.String(), "webhook warn")
+
+	require.Equal(t, map[string]string{"": "ERROR", "http": "INFO"}, logg.Levels())
+	require.ErrorIs(t, logg.SetLevel("http", "LOUD"), ErrUnknownLevel)
+
+	require.ErrorIs(t, logg.ApplyLevels("INFO", map[string]string{"app": "LOUD"}), ErrUnknownLevel)
+	require.Equal(t, map[string]string{"": "ERROR", "http": "INFO"}, logg.Levels())
+
+	require.NoError(t, logg.ApplyLevels("INFO", map[string]string{"app": "DEBUG"}))
+	require.Equal(t, map[string]string{"": "INFO", "app": "DEBUG"}, logg.Levels())
+
+	apply, err := logg.PrepareLevels("WARN", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"": "INFO", "app": "DEBUG"}, logg.Levels(), "nothing changes until applied")
+	apply()
+	require.Equal(t, map[string]string{"": "WARN"}, logg.Levels())
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "calendar.log")
+
+	file, err := newRotatingFile(path, 64, 2)
+	require.NoError(t, err)
+
+	line := bytes.Repeat([]byte("x"), 40)
+	for i := 0; i < 5; i++ {
+		_, err := file.Write(line)
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, line, data)
+	}
+	require.NoFileExists(t, path+".3")
+}
+
+func TestFileRotationWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+
+	file, err := newRotatingFile(path, 64, 0)
+	require.NoError(t, err)
+
+	line := bytes.Repeat([]byte("x"), 40)
+	for i := 0; i < 3; i++ {
+		_, err := file.Write(line)
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, line, data)
+	require.NoFileExists(t, path+".1")
+}
+
+func TestFailedRotationKeepsWriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+
+	file, err := newRotatingFile(path, 64, 1)
+	require.NoError(t, err)
+	defer file.Close()
+
+	// a directory in the backup's place makes the rename fail
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "taken"), 0o755))
+
+	line := bytes.Repeat([]byte("x"), 40)
+	_, err = file.Write(line)
+	require.NoError(t, err)
+	n, err := file.Write(line)
+	require.Error(t, err)
+	require.Equal(t, len(line), n)
+
+	require.NoError(t, os.RemoveAll(path+".1"))
+	_, err = file.Write(line)
+	require.NoError(t, err, "the next write rotates again")
+
+	data, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, append(line, line...), data)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, line, data)
+}
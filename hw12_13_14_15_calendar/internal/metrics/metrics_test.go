@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := New()
+	m.ObserveHTTP("/events", http.MethodGet, http.StatusOK, 10*time.Millisecond)
+	m.ObserveStorage("memory", "AddEvent", time.Millisecond, nil)
+	m.ObserveStorage("memory", "AddEvent", time.Millisecond, errors.New("boom"))
+	m.SetQueueDepth("webhooks", 3)
+	m.ObserveDelivery("webhooks", "delivered")
+	m.SetLeader("scheduler", true)
+	m.ObserveCache("events", true)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	for _, series := range []string{
+		`calendar_http_requests_total{method="GET",route="/events",status="200"} 1`,
+		`calendar_http_request_duration_seconds_count{method="GET",route="/events",status="200"} 1`,
+		`calendar_storage_operation_duration_seconds_count{backend="memory",operation="AddEvent"} 2`,
+		`calendar_storage_errors_total{backend="memory",operation="AddEvent"} 1`,
+		`calendar_queue_depth{queue="webhooks"} 3`,
+		`calendar_deliveries_total{outcome="delivered",queue="webhooks"} 1`,
+		`calendar_leader{name="scheduler"} 1`,
+		`calendar_leadership_changes_total{name="scheduler",state="leader"} 1`,
+		`calendar_cache_requests_total{cache="events",result="hit"} 1`,
+		`go_goroutines`,
+	} {
+		require.Contains(t, string(body), series)
+	}
+}
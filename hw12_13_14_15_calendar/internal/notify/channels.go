@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/webhook"
+)
+
+const (
+	EventReminder     = "event.reminder"
+	HeaderIdempotency = "Idempotency-Key"
+)
+
+// Channel delivers one notification to the destination in the user's preferences.
+type Channel interface {
+	Send(ctx context.Context, preferences storage.NotificationPreferences, notification storage.Notification) error
+}
+
+// emailChannel speaks plain SMTP to a local relay such as MailHog; it does
+// not authenticate or negotiate TLS.
+type emailChannel struct {
+	conf    config.SMTPConf
+	timeout time.Duration
+	now     func() time.Time
+}
+
+func (c *emailChannel) Send(
+	ctx context.Context, preferences storage.NotificationPreferences, notification storage.Notification,
+) error {
+	// preferences saved before addresses were stored bare may still carry a display name
+	to, err := mail.ParseAddress(preferences.Email)
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.conf.Addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(c.conf.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(c.conf.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(c.message(to, notification)); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (c *emailChannel) message(to *mail.Address, notification storage.Notification) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.conf.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	// titles are free text, often Cyrillic; encoding also keeps a line break from ending the header
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", c.now().Format(time.RFC1123Z))
+	// A stable Message-ID lets mail systems drop a resent reminder.
+	fmt.Fprintf(&buf, "Message-ID: <%s@calendar>\r\n", strings.ReplaceAll(notification.Key, ":", "."))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(notification.Body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+type webhookChannel struct {
+	client *http.Client
+}
+
+type WebhookPayload struct {
+	Type    string    `json:"type"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	EventID int       `json:"eventId"`
+	UserID  int       `json:"userId"`
+	Title   string    `json:"title"`
+	Date    time.Time `json:"date"`
+}
+
+func (c *webhookChannel) Send(
+	ctx context.Context, preferences storage.NotificationPreferences, notification storage.Notification,
+) error {
+	payload, err := json.Marshal(WebhookPayload{
+		Type:    EventReminder,
+		Subject: notification.Subject,
+		Body:    notification.Body,
+		EventID: notification.EventID,
+		UserID:  notification.UserID,
+		Title:   notification.Title,
+		Date:    notification.Date,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, preferences.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(webhook.HeaderEvent, EventReminder)
+	request.Header.Set(HeaderIdempotency, notification.Key)
+	tracing.Inject(ctx, request.Header)
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+	return nil
+}
+
+type logChannel struct {
+	logger Logger
+}
+
+func (c *logChannel) Send(
+	_ context.Context, _ storage.NotificationPreferences, notification storage.Notification,
+) error {
+	c.logger.Info("reminder: "+notification.Subject,
+		"user", notification.UserID, "event", notification.EventID, "key", notification.Key, "body", notification.Body)
+	return nil
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// QuietUntil returns when the quiet hours covering now end, or the zero time
+// when now is outside them. Quiet hours may span midnight, e.g. 22:00-07:00.
+func QuietUntil(preferences storage.NotificationPreferences, now time.Time) time.Time {
+	if preferences.QuietStart == "" || preferences.QuietEnd == "" {
+		return time.Time{}
+	}
+
+	start, err := time.Parse(app.ClockLayout, preferences.QuietStart)
+	if err != nil {
+		return time.Time{}
+	}
+	end, err := time.Parse(app.ClockLayout, preferences.QuietEnd)
+	if err != nil {
+		return time.Time{}
+	}
+
+	local := now.In(location(preferences))
+	minutes := clockMinutes(local)
+	from, until := clockMinutes(start), clockMinutes(end)
+
+	endOn := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, end.Hour(), end.Minute(), 0, 0, local.Location())
+	}
+
+	switch {
+	case from < until && minutes >= from && minutes < until:
+		return endOn(0)
+	case from > until && minutes >= from:
+		return endOn(1)
+	case from > until && minutes < until:
+		return endOn(0)
+	default:
+		return time.Time{}
+	}
+}
+
+func location(preferences storage.NotificationPreferences) *time.Location {
+	loc, err := time.LoadLocation(preferences.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func clockMinutes(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
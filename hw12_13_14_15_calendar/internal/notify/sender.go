@@ -0,0 +1,468 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/webhook"
+)
+
+const (
+	maxErrorLength   = 512
+	defaultInterval  = 10 * time.Second
+	defaultRetention = 7 * 24 * time.Hour
+	queueName        = "notifications"
+	// windowOverlap widens every reminder window back into the previous one,
+	// so reminders set by a transaction that committed while the previous
+	// pass ran are still found; their dispatch keys drop the repeats.
+	windowOverlap = time.Minute
+)
+
+type Logger interface {
+	Info(msg string, attrs ...any)
+	Error(msg string, attrs ...any)
+	Debug(msg string, attrs ...any)
+	Warn(msg string, attrs ...any)
+}
+
+type Storage interface {
+	AllNotificationPreferences(ctx context.Context) ([]storage.NotificationPreferences, error)
+	ListEvents(ctx context.Context, userID int, dateFrom time.Time, dateTo time.Time) ([]storage.Event, error)
+	EventReminders(ctx context.Context, eventIDs []int) (map[int][]storage.Reminder, error)
+	DueReminders(ctx context.Context, after time.Time, until time.Time) ([]storage.DueReminder, error)
+	DispatchedKeys(ctx context.Context, keys []string) (map[string]bool, error)
+	EnqueueNotifications(ctx context.Context, dispatches []storage.Dispatch, notifications []storage.Notification) error
+	ClaimNotifications(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]storage.Notification, error)
+	UpdateNotification(ctx context.Context, notification *storage.Notification) error
+	PendingNotifications(ctx context.Context) (int, error)
+	PruneDispatches(ctx context.Context, before time.Time) (int, error)
+	PruneNotifications(ctx context.Context, before time.Time) (int, error)
+}
+
+type Metrics interface {
+	SetQueueDepth(queue string, depth int)
+	ObserveDelivery(queue, outcome string)
+}
+
+// Sender reminds users of upcoming events over the channels in their
+// preferences, one notification per event and channel. Every (event, offset,
+// start) triple is recorded as a dispatch when its notifications are enqueued,
+// so rescans and restarts never schedule a reminder twice.
+type Sender struct {
+	logger    Logger
+	storage   Storage
+	metrics   Metrics
+	mu        sync.RWMutex
+	conf      config.NotificationConf
+	templates templates
+	channels  map[storage.Channel]Channel
+	now       func() time.Time
+	// lastTick ends the last reminder window enqueued in full; only Tick uses it.
+	lastTick time.Time
+}
+
+func New(logger Logger, storage Storage, conf config.NotificationConf, metrics Metrics) (*Sender, error) {
+	sender := &Sender{
+		logger:  logger,
+		storage: storage,
+		metrics: metrics,
+		now:     time.Now,
+	}
+
+	if err := sender.SetConfig(conf); err != nil {
+		return nil, err
+	}
+	return sender, nil
+}
+
+// SetConfig replaces the sender settings; it is safe to call while the sender runs.
+func (s *Sender) SetConfig(conf config.NotificationConf) error {
+	apply, err := s.PrepareConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	apply()
+	return nil
+}
+
+// PrepareConfig parses the settings for SetConfig and returns the function
+// that puts them in place, so a caller can check other settings in between.
+func (s *Sender) PrepareConfig(conf config.NotificationConf) (func(), error) {
+	if conf.Interval <= 0 {
+		conf.Interval = defaultInterval
+	}
+	if conf.Retention <= 0 {
+		conf.Retention = defaultRetention
+	}
+
+	parsed, err := parseTemplates(conf.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		s.mu.Lock()
+		previous := s.channels[storage.ChannelWebhook]
+		s.conf = conf
+		s.templates = parsed
+		s.channels = map[storage.Channel]Channel{
+			storage.ChannelEmail:   &emailChannel{conf: conf.SMTP, timeout: conf.Timeout, now: s.now},
+			storage.ChannelWebhook: &webhookChannel{client: webhook.NewClient(conf.Timeout, conf.AllowedNetworks)},
+			storage.ChannelLog:     &logChannel{logger: s.logger},
+		}
+		s.mu.Unlock()
+
+		// keep-alive connections of the replaced client would stay open until they time out
+		if channel, ok := previous.(*webhookChannel); ok {
+			channel.client.CloseIdleConnections()
+		}
+	}, nil
+}
+
+func (s *Sender) config() config.NotificationConf {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conf
+}
+
+func (s *Sender) state() (templates, map[storage.Channel]Channel) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.templates, s.channels
+}
+
+func (s *Sender) Run(ctx context.Context) {
+	interval := s.config().Interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if current := s.config().Interval; current != interval {
+			interval = current
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// Tick enqueues the reminders that came due since the last tick, and the
+// default reminders of events starting within the lead time, and then sends
+// every notification that is due.
+func (s *Sender) Tick(ctx context.Context) {
+	all, err := s.storage.AllNotificationPreferences(ctx)
+	if err != nil {
+		s.logger.Error("failed to load notification preferences: " + err.Error())
+		return
+	}
+
+	now := s.now()
+	after := s.lastTick
+	if after.IsZero() {
+		// nothing older than the longest offset can still be due
+		after = now.Add(-app.MaxReminderOffset)
+	}
+
+	due, err := s.storage.DueReminders(ctx, after.Add(-windowOverlap), now)
+	if err != nil {
+		s.logger.Error("failed to load due reminders: " + err.Error())
+	}
+	dueByUser := make(map[int][]storage.DueReminder)
+	for _, item := range due {
+		dueByUser[item.Event.UserID] = append(dueByUser[item.Event.UserID], item)
+	}
+
+	// the window only moves on once every user's reminders were enqueued
+	complete := err == nil
+	byUser := make(map[int]storage.NotificationPreferences, len(all))
+	for _, preferences := range all {
+		byUser[preferences.UserID] = preferences
+
+		if err := s.enqueue(ctx, preferences, dueByUser[preferences.UserID], now); err != nil {
+			s.logger.Error("failed to enqueue notifications: "+err.Error(), "user", preferences.UserID)
+			complete = false
+		}
+	}
+	if complete {
+		s.lastTick = now
+	}
+
+	if err := s.sendDue(ctx, byUser); err != nil {
+		s.logger.Error("failed to send notifications: " + err.Error())
+	}
+
+	// Scans start at now, so dispatches of events that already started are never consulted again.
+	if _, err := s.storage.PruneDispatches(ctx, s.now()); err != nil {
+		s.logger.Error("failed to prune notification dispatches: " + err.Error())
+	}
+	if _, err := s.storage.PruneNotifications(ctx, s.now().Add(-s.config().Retention)); err != nil {
+		s.logger.Error("failed to prune notifications: " + err.Error())
+	}
+
+	pending, err := s.storage.PendingNotifications(ctx)
+	if err != nil {
+		s.logger.Error("failed to count pending notifications: " + err.Error())
+		return
+	}
+	s.metrics.SetQueueDepth(queueName, pending)
+}
+
+type dueReminder struct {
+	event    storage.Event
+	reminder storage.Reminder
+	key      string
+}
+
+// enqueue schedules the explicit reminders of the user that came due, and
+// the default lead reminder of the user's events that have none.
+func (s *Sender) enqueue(
+	ctx context.Context, preferences storage.NotificationPreferences, dueExplicit []storage.DueReminder, now time.Time,
+) error {
+	if len(preferences.Channels) == 0 {
+		return nil
+	}
+
+	events, reminders, err := s.withDefaultReminders(ctx, preferences.UserID, now)
+	if err != nil {
+		return err
+	}
+	for _, item := range dueExplicit {
+		if _, ok := reminders[item.Event.ID]; !ok {
+			events = append(events, item.Event)
+		}
+		reminders[item.Event.ID] = append(reminders[item.Event.ID], item.Reminder)
+	}
+
+	var (
+		due  []dueReminder
+		keys []string
+	)
+	for _, event := range events {
+		for _, reminder := range dueReminders(reminders[event.ID], event.Date.Sub(now)) {
+			key := DispatchKey(event, reminder)
+			due = append(due, dueReminder{event: event, reminder: reminder, key: key})
+			keys = append(keys, key)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	dispatched, err := s.storage.DispatchedKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	tmpl, _ := s.state()
+	var (
+		dispatches    []storage.Dispatch
+		notifications []storage.Notification
+	)
+	for _, item := range due {
+		if dispatched[item.key] {
+			continue
+		}
+
+		subject, body, err := tmpl.render(item.event, preferences)
+		if err != nil {
+			return err
+		}
+
+		dispatches = append(dispatches, storage.Dispatch{
+			Key:       item.key,
+			EventID:   item.event.ID,
+			UserID:    item.event.UserID,
+			Offset:    item.reminder.Offset,
+			EventDate: item.event.Date,
+		})
+
+		channels := preferences.Channels
+		if item.reminder.Channel != "" {
+			channels = []storage.Channel{item.reminder.Channel}
+		}
+		for _, channel := range channels {
+			notifications = append(notifications, storage.Notification{
+				EventID:       item.event.ID,
+				UserID:        item.event.UserID,
+				Title:         item.event.Title,
+				Date:          item.event.Date,
+				Channel:       channel,
+				Key:           item.key,
+				Subject:       subject,
+				Body:          body,
+				Status:        storage.DeliveryPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+
+	if len(dispatches) == 0 {
+		return nil
+	}
+	return s.storage.EnqueueNotifications(ctx, dispatches, notifications)
+}
+
+// withDefaultReminders returns the user's events starting within the lead
+// time that have no reminders of their own, each with the default reminder
+// Lead before its start.
+func (s *Sender) withDefaultReminders(
+	ctx context.Context, userID int, now time.Time,
+) ([]storage.Event, map[int][]storage.Reminder, error) {
+	lead := s.config().Lead
+	reminders := make(map[int][]storage.Reminder)
+
+	events, err := s.storage.ListEvents(ctx, userID, now, now.Add(lead))
+	if err != nil || len(events) == 0 {
+		return nil, reminders, err
+	}
+
+	ids := make([]int, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	explicit, err := s.storage.EventReminders(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	withDefault := events[:0]
+	for _, event := range events {
+		if len(explicit[event.ID]) == 0 {
+			withDefault = append(withDefault, event)
+			reminders[event.ID] = []storage.Reminder{{EventID: event.ID, UserID: event.UserID, Offset: lead}}
+		}
+	}
+	return withDefault, reminders, nil
+}
+
+// dueReminders picks, per channel, the reminder with the smallest offset that
+// is already due. Reminders passed over this way are never sent late: once
+// the one hour reminder is due, the one day reminder no longer fires.
+func dueReminders(reminders []storage.Reminder, untilStart time.Duration) []storage.Reminder {
+	closest := make(map[storage.Channel]storage.Reminder)
+	var order []storage.Channel
+
+	for _, reminder := range reminders {
+		if reminder.Offset < untilStart {
+			continue
+		}
+
+		current, ok := closest[reminder.Channel]
+		if !ok {
+			order = append(order, reminder.Channel)
+		}
+		if !ok || reminder.Offset < current.Offset {
+			closest[reminder.Channel] = reminder
+		}
+	}
+
+	result := make([]storage.Reminder, 0, len(order))
+	for _, channel := range order {
+		result = append(result, closest[channel])
+	}
+	return result
+}
+
+// DispatchKey identifies the reminder of an event at an offset before its
+// start; a rescheduled event gets a new key and is reminded again.
+func DispatchKey(event storage.Event, reminder storage.Reminder) string {
+	key := fmt.Sprintf("reminder:%d:%d:%d", event.ID, int64(reminder.Offset.Seconds()), event.Date.Unix())
+	if reminder.Channel != "" {
+		key += ":" + string(reminder.Channel)
+	}
+	return key
+}
+
+func (s *Sender) sendDue(ctx context.Context, byUser map[int]storage.NotificationPreferences) error {
+	now := s.now()
+	conf := s.config()
+
+	// Notifications are sent one by one, so the lease covers a whole batch timing out.
+	lease := conf.Timeout * time.Duration(conf.BatchSize)
+	due, err := s.storage.ClaimNotifications(ctx, now, now.Add(lease), conf.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		notification := &due[i]
+		preferences, ok := byUser[notification.UserID]
+
+		switch quietUntil := QuietUntil(preferences, now); {
+		case !ok || !preferences.Enabled(notification.Channel):
+			notification.Status = storage.NotificationSkipped
+			notification.Error = "channel disabled"
+			s.metrics.ObserveDelivery(queueName, string(storage.NotificationSkipped))
+		case !quietUntil.IsZero() && !quietUntil.Before(notification.Date):
+			notification.Status = storage.NotificationSkipped
+			notification.Error = "event starts during quiet hours"
+			s.metrics.ObserveDelivery(queueName, string(storage.NotificationSkipped))
+		case !quietUntil.IsZero():
+			notification.NextAttemptAt = quietUntil
+		default:
+			s.attempt(ctx, preferences, notification)
+		}
+
+		if err := s.storage.UpdateNotification(ctx, notification); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Sender) attempt(
+	ctx context.Context, preferences storage.NotificationPreferences, notification *storage.Notification,
+) {
+	notification.Attempts++
+	_, channels := s.state()
+
+	ctx, span := tracing.Start(ctx, "notify.send")
+	span.SetAttribute("notification.id", notification.ID)
+	span.SetAttribute("notification.channel", string(notification.Channel))
+	span.SetAttribute("notification.attempt", notification.Attempts)
+
+	err := channels[notification.Channel].Send(ctx, preferences, *notification)
+	span.End(&err)
+
+	if err == nil {
+		notification.Status = storage.DeliveryDelivered
+		notification.Error = ""
+		s.metrics.ObserveDelivery(queueName, string(storage.DeliveryDelivered))
+		s.logger.Debug("notification sent", "notification", notification.ID, "channel", notification.Channel)
+		return
+	}
+
+	notification.Error = err.Error()
+	if len(notification.Error) > maxErrorLength {
+		notification.Error = notification.Error[:maxErrorLength]
+	}
+
+	conf := s.config()
+	if notification.Attempts >= conf.MaxAttempts {
+		notification.Status = storage.DeliveryFailed
+		s.metrics.ObserveDelivery(queueName, string(storage.DeliveryFailed))
+		s.logger.Error("notification failed permanently: "+err.Error(),
+			"notification", notification.ID, "channel", notification.Channel, "attempts", notification.Attempts)
+		return
+	}
+
+	s.metrics.ObserveDelivery(queueName, "retry")
+	notification.NextAttemptAt = s.now().Add(webhook.Backoff(conf.BackoffBase, conf.BackoffMax, notification.Attempts))
+	s.logger.Warn("notification failed, will retry: "+err.Error(),
+		"notification", notification.ID, "channel", notification.Channel, "attempts", notification.Attempts,
+		"nextAttemptAt", notification.NextAttemptAt)
+}
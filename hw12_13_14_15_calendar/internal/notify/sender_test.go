@@ -0,0 +1,443 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub accepts mail like a local SMTP stand-in and keeps the message data.
+type smtpStub struct {
+	listener   net.Listener
+	mu         sync.Mutex
+	recipients []string
+	messages   []string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	stub := &smtpStub{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub ready")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.Fields(line + " x")[0]); command {
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RCPT":
+			s.mu.Lock()
+			s.recipients = append(s.recipients, strings.TrimSpace(line))
+			s.mu.Unlock()
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpStub) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func (s *smtpStub) rcpts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.recipients...)
+}
+
+func TestSender(t *testing.T) {
+	ctx := context.Background()
+	mailbox := newSMTPStub(t)
+
+	var (
+		hooks   []WebhookPayload
+		keys    []string
+		hooksMu sync.Mutex
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		hooksMu.Lock()
+		hooks = append(hooks, payload)
+		keys = append(keys, r.Header.Get(HeaderIdempotency))
+		hooksMu.Unlock()
+	}))
+	defer receiver.Close()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	conf := config.NotificationConf{
+		Lead:        time.Hour,
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Hour,
+		BatchSize:   10,
+		SMTP:        config.SMTPConf{Addr: mailbox.listener.Addr().String(), From: "calendar@localhost"},
+		Templates: config.NotificationTemplates{
+			Subject: "Reminder: {{.Title}}",
+			Body:    "{{.Title}} starts at {{.Start}}",
+		},
+		// the receiver listens on loopback, which webhook reminders may not reach by default
+		AllowedNetworks: []string{"127.0.0.1"},
+	}
+	sender, err := New(logg, storageService, conf, metrics.New())
+	require.NoError(t, err)
+	sender.now = func() time.Time { return now }
+
+	require.NoError(t, storageService.SetNotificationPreferences(ctx, &storage.NotificationPreferences{
+		UserID:     1,
+		Channels:   []storage.Channel{storage.ChannelEmail, storage.ChannelWebhook, storage.ChannelLog},
+		Email:      "User <user@example.com>", // stored before addresses were saved bare
+		WebhookURL: receiver.URL,
+		Timezone:   "Europe/Moscow",
+	}))
+
+	event := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: now.Add(30 * time.Minute)}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+
+	sender.Tick(ctx)
+	sender.Tick(ctx)
+
+	notifications, err := storageService.ListNotifications(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, notifications, 3, "one notification per channel, enqueued once")
+	for _, notification := range notifications {
+		require.Equal(t, storage.DeliveryDelivered, notification.Status, notification.Channel)
+		require.Equal(t, "Reminder: Retro", notification.Subject)
+		require.Equal(t, "Retro starts at Fri, 01 Mar 2024 15:30 MSK", notification.Body)
+	}
+
+	t.Run("Email", func(t *testing.T) {
+		messages := mailbox.received()
+		require.Len(t, messages, 1)
+		require.Contains(t, messages[0], "To: \"User\" <user@example.com>\r\n")
+		require.Contains(t, messages[0], "Subject: Reminder: Retro\r\n")
+		require.Equal(t, []string{"RCPT TO:<user@example.com>"}, mailbox.rcpts())
+	})
+
+	t.Run("Webhook", func(t *testing.T) {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		require.Len(t, hooks, 1)
+		require.Equal(t, EventReminder, hooks[0].Type)
+		require.Equal(t, event.ID, hooks[0].EventID)
+		require.Equal(t, []string{DispatchKey(*event, storage.Reminder{Offset: time.Hour})}, keys)
+	})
+
+	t.Run("Restart Does Not Resend", func(t *testing.T) {
+		restarted, err := New(logg, storageService, conf, metrics.New())
+		require.NoError(t, err)
+		restarted.now = func() time.Time { return now.Add(time.Minute) }
+		restarted.Tick(ctx)
+
+		notifications, err := storageService.ListNotifications(ctx, 1, 0)
+		require.NoError(t, err)
+		require.Len(t, notifications, 3)
+		require.Len(t, mailbox.received(), 1)
+	})
+
+	t.Run("Retry On Failure", func(t *testing.T) {
+		mailbox.listener.Close()
+
+		later := &storage.Event{UserID: 1, Title: "Demo", Duration: "0:30:00", Date: now.Add(45 * time.Minute)}
+		require.NoError(t, storageService.AddEvent(ctx, later))
+		sender.Tick(ctx)
+
+		notifications, err := storageService.ListNotifications(ctx, 1, 3)
+		require.NoError(t, err)
+		for _, notification := range notifications {
+			if notification.Channel != storage.ChannelEmail {
+				continue
+			}
+			require.Equal(t, storage.DeliveryPending, notification.Status)
+			require.Equal(t, 1, notification.Attempts)
+			require.NotEmpty(t, notification.Error)
+			require.Equal(t, now.Add(time.Minute), notification.NextAttemptAt)
+		}
+	})
+}
+
+func TestQuietHours(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	now := time.Date(2024, time.March, 1, 22, 30, 0, 0, time.UTC)
+	sender, err := New(logg, storageService, config.NotificationConf{
+		Lead:        12 * time.Hour,
+		MaxAttempts: 1,
+		BatchSize:   10,
+		Templates:   config.NotificationTemplates{Subject: "{{.Title}}", Body: "{{.Start}}"},
+	}, metrics.New())
+	require.NoError(t, err)
+	sender.now = func() time.Time { return now }
+
+	require.NoError(t, storageService.SetNotificationPreferences(ctx, &storage.NotificationPreferences{
+		UserID:     1,
+		Channels:   []storage.Channel{storage.ChannelLog},
+		QuietStart: "22:00",
+		QuietEnd:   "07:00",
+	}))
+
+	early := &storage.Event{UserID: 1, Title: "Night deploy", Duration: "1:00:00", Date: now.Add(time.Hour)}
+	require.NoError(t, storageService.AddEvent(ctx, early))
+	morning := &storage.Event{UserID: 1, Title: "Standup", Duration: "0:15:00", Date: now.Add(10 * time.Hour)}
+	require.NoError(t, storageService.AddEvent(ctx, morning))
+
+	sender.Tick(ctx)
+
+	notifications, err := storageService.ListNotifications(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+
+	byEvent := map[int]storage.Notification{}
+	for _, notification := range notifications {
+		byEvent[notification.EventID] = notification
+	}
+
+	require.Equal(t, storage.NotificationSkipped, byEvent[early.ID].Status)
+	require.Equal(t, storage.DeliveryPending, byEvent[morning.ID].Status)
+	require.Equal(t, time.Date(2024, time.March, 2, 7, 0, 0, 0, time.UTC), byEvent[morning.ID].NextAttemptAt)
+
+	now = time.Date(2024, time.March, 2, 7, 0, 0, 0, time.UTC)
+	sender.Tick(ctx)
+
+	notifications, err = storageService.ListNotifications(ctx, 1, 0)
+	require.NoError(t, err)
+	for _, notification := range notifications {
+		if notification.EventID == morning.ID {
+			require.Equal(t, storage.DeliveryDelivered, notification.Status)
+		}
+	}
+}
+
+func TestWebhookTargets(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls.Add(1)
+	}))
+	defer receiver.Close()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	sender, err := New(logg, storageService, config.NotificationConf{
+		Lead:        time.Hour,
+		Timeout:     time.Second,
+		MaxAttempts: 1,
+		BatchSize:   10,
+		Templates:   config.NotificationTemplates{Subject: "{{.Title}}", Body: "{{.Start}}"},
+	}, metrics.New())
+	require.NoError(t, err)
+	sender.now = func() time.Time { return now }
+
+	preferences := &storage.NotificationPreferences{
+		UserID:     1,
+		Channels:   []storage.Channel{storage.ChannelWebhook},
+		WebhookURL: receiver.URL,
+	}
+	require.NoError(t, storageService.SetNotificationPreferences(ctx, preferences))
+	event := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: now.Add(30 * time.Minute)}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+
+	sender.Tick(ctx)
+
+	notifications, err := storageService.ListNotifications(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, storage.DeliveryFailed, notifications[0].Status)
+	require.Contains(t, notifications[0].Error, webhook.ErrAddressNotAllowed.Error())
+	require.Zero(t, calls.Load(), "loopback is refused unless allowed")
+
+	t.Run("Disabled Channel Skipped", func(t *testing.T) {
+		later := &storage.Event{UserID: 1, Title: "Demo", Duration: "1:00:00", Date: now.Add(45 * time.Minute)}
+		require.NoError(t, storageService.AddEvent(ctx, later))
+
+		// the reminder is enqueued for the webhook channel, which is turned off before it is sent
+		require.NoError(t, sender.enqueue(ctx, *preferences, nil, now))
+		preferences.Channels = []storage.Channel{storage.ChannelLog}
+		require.NoError(t, storageService.SetNotificationPreferences(ctx, preferences))
+		sender.Tick(ctx)
+
+		notifications, err := storageService.ListNotifications(ctx, 1, 0)
+		require.NoError(t, err)
+		skipped := 0
+		for _, notification := range notifications {
+			if notification.EventID == later.ID && notification.Channel == storage.ChannelWebhook {
+				require.Equal(t, storage.NotificationSkipped, notification.Status)
+				require.Equal(t, "channel disabled", notification.Error)
+				skipped++
+			}
+		}
+		require.Equal(t, 1, skipped)
+	})
+}
+
+func TestEmailMessage(t *testing.T) {
+	channel := &emailChannel{conf: config.SMTPConf{From: "calendar@localhost"}, now: time.Now}
+	to := &mail.Address{Name: "Иван", Address: "ivan@example.com"}
+
+	message, err := mail.ReadMessage(strings.NewReader(string(channel.message(to, storage.Notification{
+		Key:     "reminder:1:3600:1709294400",
+		Subject: "Напоминание: Ретро\r\nBcc: x@example.com",
+		Body:    "Ретро в 15:30",
+	}))))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Напоминание: Ретро\r\nBcc: x@example.com", subject)
+	require.Empty(t, message.Header.Get("Bcc"), "a line break in the title must not add headers")
+
+	recipients, err := message.Header.AddressList("To")
+	require.NoError(t, err)
+	require.Equal(t, []*mail.Address{to}, recipients)
+}
+
+func TestQuietUntil(t *testing.T) {
+	preferences := storage.NotificationPreferences{QuietStart: "09:00", QuietEnd: "17:00", Timezone: "Europe/Moscow"}
+
+	at := func(hour int) time.Time { return time.Date(2024, time.March, 1, hour, 0, 0, 0, time.UTC) }
+	require.True(t, QuietUntil(preferences, at(5)).IsZero(), "08:00 in Moscow")
+	require.True(t, QuietUntil(preferences, at(6)).Equal(at(14)), "09:00 in Moscow")
+	require.True(t, QuietUntil(preferences, at(14)).IsZero(), "17:00 in Moscow")
+	require.True(t, QuietUntil(storage.NotificationPreferences{}, at(6)).IsZero())
+}
+
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	// the storage stamps overdue reminders with the real clock, so the sender's runs just ahead of it
+	now := time.Now().UTC().Add(time.Second)
+	sender, err := New(logg, storageService, config.NotificationConf{
+		Lead:        15 * time.Minute,
+		MaxAttempts: 1,
+		BatchSize:   10,
+		Templates:   config.NotificationTemplates{Subject: "{{.Title}}", Body: "{{.Start}}"},
+	}, metrics.New())
+	require.NoError(t, err)
+	sender.now = func() time.Time { return now }
+
+	require.NoError(t, storageService.SetNotificationPreferences(ctx, &storage.NotificationPreferences{
+		UserID:   1,
+		Channels: []storage.Channel{storage.ChannelLog, storage.ChannelWebhook},
+	}))
+
+	event := &storage.Event{UserID: 1, Title: "Review", Duration: "1:00:00", Date: now.Add(2 * time.Hour)}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+	require.NoError(t, storageService.SetReminders(ctx, event.ID, 1, []storage.Reminder{
+		{Offset: 24 * time.Hour, Channel: storage.ChannelLog},
+		{Offset: time.Hour, Channel: storage.ChannelLog},
+		{Offset: 10 * time.Minute, Channel: storage.ChannelLog},
+	}))
+
+	sent := func() []string {
+		notifications, err := storageService.ListNotifications(ctx, 1, 0)
+		require.NoError(t, err)
+
+		keys := make([]string, 0, len(notifications))
+		for _, notification := range notifications {
+			require.Equal(t, storage.ChannelLog, notification.Channel, "reminders name their channel")
+			keys = append(keys, notification.Key)
+		}
+		return keys
+	}
+	key := func(offset time.Duration) string {
+		return DispatchKey(*event, storage.Reminder{Offset: offset, Channel: storage.ChannelLog})
+	}
+
+	sender.Tick(ctx)
+	require.ElementsMatch(t, []string{key(24 * time.Hour)}, sent(), "only the widest overdue reminder fires")
+
+	now = now.Add(65 * time.Minute)
+	sender.Tick(ctx)
+	sender.Tick(ctx)
+	require.ElementsMatch(t, []string{key(24 * time.Hour), key(time.Hour)}, sent())
+
+	now = now.Add(50 * time.Minute)
+	sender.Tick(ctx)
+	require.ElementsMatch(t, []string{key(24 * time.Hour), key(time.Hour), key(10 * time.Minute)}, sent())
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const startLayout = "Mon, 02 Jan 2006 15:04 MST"
+
+// TemplateData is what subject and body templates are rendered with.
+type TemplateData struct {
+	EventID     int
+	UserID      int
+	Title       string
+	Description string
+	Start       string
+	Duration    string
+}
+
+type templates struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func parseTemplates(conf config.NotificationTemplates) (templates, error) {
+	subject, err := template.New("subject").Parse(conf.Subject)
+	if err != nil {
+		return templates{}, err
+	}
+
+	body, err := template.New("body").Parse(conf.Body)
+	if err != nil {
+		return templates{}, err
+	}
+
+	return templates{subject: subject, body: body}, nil
+}
+
+func (t templates) render(
+	event storage.Event, preferences storage.NotificationPreferences,
+) (subject string, body string, err error) {
+	data := TemplateData{
+		EventID:     event.ID,
+		UserID:      event.UserID,
+		Title:       event.Title,
+		Description: event.Description,
+		Start:       event.Date.In(location(preferences)).Format(startLayout),
+		Duration:    event.Duration,
+	}
+
+	var buf strings.Builder
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+	// The subject becomes a mail header, so it must stay on one line.
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.body.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+
+	return subject, buf.String(), nil
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := New(Limit{Rate: 2, Burst: 3})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := limiter.Allow("a")
+		require.True(t, ok)
+	}
+
+	ok, wait := limiter.Allow("a")
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _ = limiter.Allow("b")
+	require.True(t, ok, "keys have separate buckets")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = limiter.Allow("a")
+	require.True(t, ok)
+
+	limiter.SetLimit(Limit{})
+	ok, _ = limiter.Allow("a")
+	require.True(t, ok, "zero rate disables limiting")
+}
+
+func TestLimiterSweep(t *testing.T) {
+	now := time.Now()
+	limiter := New(Limit{Rate: 1, Burst: 1})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("a")
+	require.Len(t, limiter.buckets, 1)
+
+	now = now.Add(2 * sweepInterval)
+	limiter.Allow("b")
+	require.Len(t, limiter.buckets, 1)
+	require.Contains(t, limiter.buckets, "b")
+}
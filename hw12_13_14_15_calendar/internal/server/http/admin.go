@@ -0,0 +1,85 @@
+package internalhttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrAdminDisabled = errors.New("admin endpoints are disabled, set http.adminToken to enable them")
+	ErrUnauthorized  = errors.New("missing or invalid admin token")
+)
+
+type LogLevels interface {
+	Levels() map[string]string
+	SetLevel(name string, level string) error
+	ResetLevel(name string)
+}
+
+type logLevelRequest struct {
+	Package string `json:"package,omitempty"`
+	Level   string `json:"level"`
+}
+
+type logLevelsResponse struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+// requireAdmin lets through requests bearing the configured admin token. The
+// endpoints it guards change process-wide state, so without a token they are
+// refused altogether.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			s.writeError(w, r, ErrAdminDisabled)
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			s.writeError(w, r, ErrUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// logLevelHandler reads and changes log levels at runtime: PUT sets the root
+// level or a package override, DELETE ?package= drops an override.
+func (s *Server) logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var request logLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			s.writeError(w, r, ErrInvalidBody)
+			return
+		}
+
+		if err := s.logLevels.SetLevel(request.Package, request.Level); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.logger.InfoContext(r.Context(), "log level changed", "package", request.Package, "level", request.Level)
+	case http.MethodDelete:
+		s.logLevels.ResetLevel(r.URL.Query().Get("package"))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	levels := s.logLevels.Levels()
+	response := logLevelsResponse{Level: levels[""], Packages: make(map[string]string, len(levels))}
+	for name, level := range levels {
+		if name != "" {
+			response.Packages[name] = level
+		}
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,43 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		status        int
+	}{
+		{name: "Disabled Without Token", authorization: "Bearer anything", status: http.StatusForbidden},
+		{name: "Missing Token", token: "secret", status: http.StatusUnauthorized},
+		{name: "Wrong Token", token: "secret", authorization: "Bearer guess", status: http.StatusUnauthorized},
+		{name: "Not A Bearer Token", token: "secret", authorization: "secret", status: http.StatusUnauthorized},
+		{name: "Valid Token", token: "secret", authorization: "Bearer secret", status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.adminToken = tc.token
+
+			request := httptest.NewRequest(http.MethodGet, "/admin/log-level", nil)
+			if tc.authorization != "" {
+				request.Header.Set("Authorization", tc.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			if tc.status == http.StatusUnauthorized {
+				require.Equal(t, `Bearer realm="admin"`, recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
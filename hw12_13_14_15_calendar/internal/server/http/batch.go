@@ -0,0 +1,130 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	batchPartial = "partial"
+	batchAtomic  = "atomic"
+
+	// maxBatchOperationSize is the body allowance per operation, so a batch
+	// body holds at most app.MaxBatchSize operations of that size.
+	maxBatchOperationSize = 4 << 10
+	maxBatchBodySize      = app.MaxBatchSize * maxBatchOperationSize
+)
+
+var (
+	ErrInvalidBatchMode  = errors.New("invalid batch mode, expected partial or atomic")
+	ErrBatchBodyTooLarge = errors.New("batch body is too large")
+)
+
+type batchEvent struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Date        time.Time `json:"date,omitempty"`
+	Duration    string    `json:"duration,omitempty"`
+}
+
+// batchOperation creates Event, or updates or deletes the event ID; updates
+// only change the fields that are set.
+type batchOperation struct {
+	Op    storage.BatchOp `json:"op"`
+	ID    int             `json:"id,omitempty"`
+	Event *batchEvent     `json:"event,omitempty"`
+}
+
+type batchRequest struct {
+	Mode       string           `json:"mode,omitempty"`
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchResult struct {
+	Op    storage.BatchOp `json:"op"`
+	Event *eventResponse  `json:"event,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Applied int           `json:"applied"`
+	Failed  int           `json:"failed"`
+	Results []batchResult `json:"results"`
+}
+
+// batchHandler answers 200 unless an all-or-nothing batch was rolled back,
+// which is a 422 carrying the same per-operation results.
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	var (
+		request  batchRequest
+		tooLarge *http.MaxBytesError
+	)
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize)).Decode(&request)
+	if errors.As(err, &tooLarge) {
+		s.writeError(w, r, ErrBatchBodyTooLarge)
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, ErrInvalidBody)
+		return
+	}
+	if request.Mode != "" && request.Mode != batchPartial && request.Mode != batchAtomic {
+		s.writeError(w, r, ErrInvalidBatchMode)
+		return
+	}
+
+	items := make([]storage.BatchItem, 0, len(request.Operations))
+	for _, operation := range request.Operations {
+		event := storage.Event{ID: operation.ID, UserID: userID}
+		if operation.Event != nil {
+			event.Title = operation.Event.Title
+			event.Description = operation.Event.Description
+			event.Date = operation.Event.Date
+			event.Duration = operation.Event.Duration
+		}
+		items = append(items, storage.BatchItem{Op: operation.Op, Event: event})
+	}
+
+	results, err := s.app.ApplyBatch(r.Context(), items, request.Mode == batchAtomic)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := batchResponse{Results: make([]batchResult, 0, len(results))}
+	for i, result := range results {
+		item := batchResult{Op: items[i].Op}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+			response.Failed++
+		} else {
+			event := newEventResponse(result.Event)
+			item.Event = &event
+			response.Applied++
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	status := http.StatusOK
+	if response.Applied == 0 && request.Mode == batchAtomic {
+		status = http.StatusUnprocessableEntity
+	}
+	s.writeJSON(w, r, status, response)
+}
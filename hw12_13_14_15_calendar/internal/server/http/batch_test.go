@@ -0,0 +1,31 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchBodyLimit(t *testing.T) {
+	handler := newTestServer(t).Handler()
+
+	batch := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/events/batch?user_id=1", strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	operation := `{"op":"create","event":{"title":"Standup","date":"2024-03-04T10:00:00Z","duration":"0:15:00"}}`
+	recorder := batch(`{"operations":[` + operation + `]}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	// whitespace alone is enough to go past the limit
+	padding := strings.Repeat(" ", maxBatchBodySize)
+	recorder = batch(`{"operations":[` + operation + padding + `]}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	require.Contains(t, recorder.Body.String(), ErrBatchBodyTooLarge.Error())
+}
@@ -0,0 +1,105 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var (
+	ErrInvalidResumeToken   = errors.New("invalid resume token")
+	ErrStreamingUnsupported = errors.New("streaming unsupported")
+)
+
+type changeResponse struct {
+	ID        int64         `json:"id"`
+	Type      string        `json:"type"`
+	EventID   int           `json:"eventId"`
+	UserID    int           `json:"userId"`
+	Event     eventResponse `json:"event"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// changesHandler streams changes as Server-Sent Events. The SSE id of every
+// message is its resume token: clients reconnect with Last-Event-ID (or ?after=)
+// to continue right after the last change they have seen.
+func (s *Server) changesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	after, err := resumeToken(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, r, ErrStreamingUnsupported)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stop := context.AfterFunc(s.closing, cancel)
+	defer stop()
+
+	err = s.app.StreamChanges(ctx, userID, after, func(change storage.Change) error {
+		data, err := json.Marshal(changeResponse{
+			ID:        change.ID,
+			Type:      string(change.Type),
+			EventID:   change.EventID,
+			UserID:    change.UserID,
+			Event:     newEventResponse(change.Event),
+			CreatedAt: change.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.logger.ErrorContext(r.Context(), "change stream failed: "+err.Error())
+	}
+}
+
+func resumeToken(r *http.Request) (int64, error) {
+	token := r.Header.Get("Last-Event-ID")
+	if token == "" {
+		token = r.URL.Query().Get("after")
+	}
+	if token == "" {
+		return 0, nil
+	}
+
+	after, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || after < 0 {
+		return 0, ErrInvalidResumeToken
+	}
+	return after, nil
+}
@@ -0,0 +1,46 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEventsETag(t *testing.T) {
+	handler := newTestServer(t).Handler()
+
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/events?user_id=1&date=2024-03-01&range=month", nil)
+		if ifNoneMatch != "" {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := list("")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	unchanged := list(`"other", W/` + etag)
+	require.Equal(t, http.StatusNotModified, unchanged.Code)
+	require.Empty(t, unchanged.Body.String())
+	require.Equal(t, etag, unchanged.Header().Get("ETag"))
+
+	batch := httptest.NewRequest(http.MethodPost, "/events/batch?user_id=1", strings.NewReader(`{"operations":[
+		{"op":"create","event":{"title":"Standup","date":"2024-03-04T10:00:00Z","duration":"0:15:00"}}
+	]}`))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, batch)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	changed := list(etag)
+	require.Equal(t, http.StatusOK, changed.Code)
+	require.NotEqual(t, etag, changed.Header().Get("ETag"))
+	require.Contains(t, changed.Body.String(), "Standup")
+}
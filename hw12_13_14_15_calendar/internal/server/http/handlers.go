@@ -0,0 +1,265 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrInvalidUserID  = errors.New("invalid user_id")
+	ErrInvalidDate    = errors.New("invalid date")
+	ErrInvalidLimit   = errors.New("invalid limit")
+	ErrInvalidEventID = errors.New("invalid event id")
+)
+
+var dateRanges = map[string]int{
+	"day":   app.DAY,
+	"week":  app.WEEK,
+	"month": app.MONTH,
+}
+
+type eventResponse struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Date        time.Time  `json:"date"`
+	Duration    string     `json:"duration"`
+	UserID      int        `json:"userId"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+type eventsPageResponse struct {
+	Events     []eventResponse `json:"events"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listEvents(w, r)
+	case http.MethodDelete:
+		s.deleteEvent(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := strconv.Atoi(query.Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	dateFrom, err := time.Parse(dateLayout, query.Get("date"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidDate)
+		return
+	}
+
+	dateRange, ok := dateRanges[query.Get("range")]
+	if !ok {
+		s.writeError(w, r, app.ErrDateRange)
+		return
+	}
+
+	var limit int
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			s.writeError(w, r, ErrInvalidLimit)
+			return
+		}
+	}
+
+	page, err := s.app.GetEventsPage(r.Context(), userID, dateFrom, dateRange, query.Get("cursor"), limit)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := eventsPageResponse{
+		Events:     make([]eventResponse, 0, len(page.Events)),
+		NextCursor: page.NextCursor,
+	}
+	for _, event := range page.Events {
+		response.Events = append(response.Events, newEventResponse(event))
+	}
+
+	s.writeTaggedJSON(w, r, response)
+}
+
+func (s *Server) deleteEvent(w http.ResponseWriter, r *http.Request) {
+	userID, id, err := eventKey(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.app.DeleteEvent(r.Context(), id, userID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) trashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	events, err := s.app.ListTrash(r.Context(), userID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := make([]eventResponse, 0, len(events))
+	for _, event := range events {
+		response = append(response, newEventResponse(event))
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
+
+func (s *Server) restoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, id, err := eventKey(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.app.RestoreEvent(r.Context(), id, userID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func eventKey(r *http.Request) (userID int, id int, err error) {
+	query := r.URL.Query()
+
+	userID, err = strconv.Atoi(query.Get("user_id"))
+	if err != nil {
+		return 0, 0, ErrInvalidUserID
+	}
+
+	id, err = strconv.Atoi(query.Get("id"))
+	if err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+
+	return userID, id, nil
+}
+
+func newEventResponse(event storage.Event) eventResponse {
+	return eventResponse{
+		ID:          event.ID,
+		Title:       event.Title,
+		Description: event.Description,
+		Date:        event.Date,
+		Duration:    event.Duration,
+		UserID:      event.UserID,
+		DeletedAt:   event.DeletedAt,
+	}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.ErrorContext(r.Context(), "failed to write response: "+err.Error())
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		s.logger.ErrorContext(r.Context(), "request failed: "+err.Error())
+	}
+
+	s.writeJSON(w, r, status, errorResponse{Error: err.Error()})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidUserID),
+		errors.Is(err, ErrInvalidDate),
+		errors.Is(err, ErrInvalidLimit),
+		errors.Is(err, ErrInvalidEventID),
+		errors.Is(err, ErrInvalidRevision),
+		errors.Is(err, app.ErrRevisionRequired),
+		errors.Is(err, app.ErrEventIDRequired),
+		errors.Is(err, ErrInvalidResumeToken),
+		errors.Is(err, ErrInvalidBody),
+		errors.Is(err, ErrInvalidWebhookID),
+		errors.Is(err, app.ErrWebhookURLInvalid),
+		errors.Is(err, app.ErrWebhookEventType),
+		errors.Is(err, app.ErrWebhookIDRequired),
+		errors.Is(err, app.ErrDateRange),
+		errors.Is(err, app.ErrInvalidCursor),
+		errors.Is(err, app.ErrUserIDRequired),
+		errors.Is(err, app.ErrNotificationChannel),
+		errors.Is(err, app.ErrNotificationEmail),
+		errors.Is(err, app.ErrNotificationURL),
+		errors.Is(err, app.ErrQuietHours),
+		errors.Is(err, app.ErrTimezone),
+		errors.Is(err, ErrInvalidReminderOffset),
+		errors.Is(err, ErrInvalidBatchMode),
+		errors.Is(err, app.ErrBatchEmpty),
+		errors.Is(err, app.ErrBatchTooLarge),
+		errors.Is(err, app.ErrReminderOffset),
+		errors.Is(err, app.ErrReminderDuplicate),
+		errors.Is(err, app.ErrTooManyReminders),
+		errors.Is(err, logger.ErrUnknownLevel):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrAdminDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, app.ErrWebhookNotFound),
+		errors.Is(err, app.ErrEventNotFound),
+		errors.Is(err, app.ErrEventNotInTrash),
+		errors.Is(err, app.ErrRevisionNotFound),
+		errors.Is(err, app.ErrPreferencesNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, app.ErrRestoreWindowExpired):
+		return http.StatusGone
+	case errors.Is(err, ErrBatchBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,28 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/health"
+)
+
+type Health interface {
+	Live() health.Report
+	Ready(ctx context.Context) health.Report
+}
+
+func (s *Server) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, r, http.StatusOK, s.health.Live())
+}
+
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Ready(r.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	s.writeJSON(w, r, status, report)
+}
@@ -0,0 +1,88 @@
+package internalhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var ErrInvalidRevision = errors.New("invalid revision")
+
+type revisionResponse struct {
+	Revision     int                   `json:"revision"`
+	Action       string                `json:"action"`
+	Actor        string                `json:"actor"`
+	Diff         []storage.FieldChange `json:"diff"`
+	Snapshot     eventResponse         `json:"snapshot"`
+	RevertedFrom int                   `json:"revertedFrom,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+}
+
+func (s *Server) historyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, id, err := eventKey(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	revisions, err := s.app.EventHistory(r.Context(), id, userID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := make([]revisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		diff := revision.Diff
+		if diff == nil {
+			diff = []storage.FieldChange{}
+		}
+
+		response = append(response, revisionResponse{
+			Revision:     revision.Revision,
+			Action:       string(revision.Action),
+			Actor:        revision.Actor,
+			Diff:         diff,
+			Snapshot:     newEventResponse(revision.Snapshot),
+			RevertedFrom: revision.RevertedFrom,
+			CreatedAt:    revision.CreatedAt,
+		})
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
+
+func (s *Server) revertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, id, err := eventKey(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	revision, err := strconv.Atoi(r.URL.Query().Get("revision"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidRevision)
+		return
+	}
+
+	event, err := s.app.RevertEvent(r.Context(), id, userID, revision)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusOK, newEventResponse(*event))
+}
@@ -1,16 +1,166 @@
 package internalhttp
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	ActorHeader     = "X-Actor"
+
+	maxRequestIDLength = 128
 )
 
-func loggingMiddleware(next http.Handler) http.Handler {
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(body []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(body)
+	r.size += n
+	return n, err
+}
+
+// Flush keeps Server-Sent Events working through the recorder.
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func metricsMiddleware(metrics Metrics, route string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
+		recorder := newResponseRecorder(w)
+		next.ServeHTTP(recorder, r)
+		metrics.ObserveHTTP(route, r.Method, recorder.status, time.Since(startTime))
+	})
+}
+
+// tracingMiddleware continues the caller's trace from the traceparent header
+// and returns the server span's context in the response headers.
+func tracingMiddleware(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "HTTP "+r.Method+" "+route)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", route)
+		tracing.Inject(ctx, w.Header())
+
+		recorder := newResponseRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", recorder.status)
+		var err error
+		if recorder.status >= http.StatusInternalServerError {
+			err = fmt.Errorf("http status %d", recorder.status)
+		}
+		span.End(&err)
+	})
+}
+
+func loggingMiddleware(logg Logger, route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		recorder := newResponseRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		logg.InfoContext(r.Context(), "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"proto", r.Proto,
+			"status", recorder.status,
+			"size", recorder.size,
+			"duration", time.Since(startTime),
+			"ip", clientIP(r),
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
+
+// requestIDMiddleware propagates the caller's X-Request-ID or assigns a new
+// one, echoes it in the response and stores it in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// actorMiddleware records who performs the request for the event history: the
+// user the request is made for. The API has no authentication of its own, so
+// the X-Actor header naming someone else is only believed when the request
+// comes straight from a trusted proxy, such as an authenticating gateway.
+func actorMiddleware(trusted []*net.IPNet, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var actor string
+
+		remote, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			remote = r.RemoteAddr
+		}
+		if isTrusted(remote, trusted) {
+			actor = r.Header.Get(ActorHeader)
+		}
+
+		if actor == "" {
+			if userID := r.URL.Query().Get("user_id"); userID != "" {
+				actor = "user:" + userID
+			}
+		}
+
+		if actor != "" {
+			r = r.WithContext(app.WithActor(r.Context(), actor))
+		}
 		next.ServeHTTP(w, r)
-		duration := time.Since(startTime)
-		log.Printf("Request [%s %s] took %s", r.Method, r.URL.Path, duration)
 	})
 }
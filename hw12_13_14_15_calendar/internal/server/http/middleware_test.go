@@ -0,0 +1,105 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	requestID string
+	attrs     []any
+}
+
+func (l *recordingLogger) Info(string, ...any)  {}
+func (l *recordingLogger) Error(string, ...any) {}
+func (l *recordingLogger) Debug(string, ...any) {}
+func (l *recordingLogger) Warn(string, ...any)  {}
+
+func (l *recordingLogger) InfoContext(ctx context.Context, _ string, attrs ...any) {
+	l.requestID = logger.RequestIDFromContext(ctx)
+	l.attrs = attrs
+}
+
+func (l *recordingLogger) ErrorContext(context.Context, string, ...any) {}
+
+func attr(attrs []any, key string) any {
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i] == key {
+			return attrs[i+1]
+		}
+	}
+	return nil
+}
+
+func TestRequestLogging(t *testing.T) {
+	logg := &recordingLogger{}
+	handler := requestIDMiddleware(loggingMiddleware(logg, "/teapot", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			_, _ = w.Write([]byte("short and stout"))
+		},
+	)))
+
+	t.Run("propagates request id", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/teapot", nil)
+		request.Header.Set(RequestIDHeader, "abc-123")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		require.Equal(t, "abc-123", recorder.Header().Get(RequestIDHeader))
+		require.Equal(t, "abc-123", logg.requestID)
+		require.Equal(t, http.StatusTeapot, attr(logg.attrs, "status"))
+		require.Equal(t, len("short and stout"), attr(logg.attrs, "size"))
+		require.Equal(t, "/teapot", attr(logg.attrs, "route"))
+	})
+
+	t.Run("assigns request id", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/teapot", nil)
+		request.Header.Set(RequestIDHeader, "bad id\n")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		requestID := recorder.Header().Get(RequestIDHeader)
+		require.Len(t, requestID, 32)
+		require.Equal(t, requestID, logg.requestID)
+	})
+}
+
+func TestActorMiddleware(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	var actor string
+	handler := actorMiddleware(trusted, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		actor = app.ActorFromContext(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		header string
+		actor  string
+	}{
+		{name: "user", remote: "192.0.2.1:1234", actor: "user:7"},
+		{name: "header from client ignored", remote: "192.0.2.1:1234", header: "admin", actor: "user:7"},
+		{name: "header from trusted proxy", remote: "10.0.0.1:1234", header: "alice", actor: "alice"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodDelete, "/events?user_id=7&id=1", nil)
+			request.RemoteAddr = tc.remote
+			if tc.header != "" {
+				request.Header.Set(ActorHeader, tc.header)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+			require.Equal(t, tc.actor, actor)
+		})
+	}
+}
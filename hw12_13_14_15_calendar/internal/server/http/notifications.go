@@ -0,0 +1,163 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type preferencesRequest struct {
+	UserID     int      `json:"userId"`
+	Channels   []string `json:"channels"`
+	Email      string   `json:"email,omitempty"`
+	WebhookURL string   `json:"webhookUrl,omitempty"`
+	QuietStart string   `json:"quietStart,omitempty"`
+	QuietEnd   string   `json:"quietEnd,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+}
+
+type preferencesResponse struct {
+	UserID     int       `json:"userId"`
+	Channels   []string  `json:"channels"`
+	Email      string    `json:"email,omitempty"`
+	WebhookURL string    `json:"webhookUrl,omitempty"`
+	QuietStart string    `json:"quietStart,omitempty"`
+	QuietEnd   string    `json:"quietEnd,omitempty"`
+	Timezone   string    `json:"timezone,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type notificationResponse struct {
+	ID            int       `json:"id"`
+	EventID       int       `json:"eventId"`
+	UserID        int       `json:"userId"`
+	Title         string    `json:"title"`
+	Date          time.Time `json:"date"`
+	Channel       string    `json:"channel"`
+	Subject       string    `json:"subject"`
+	Body          string    `json:"body"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	Error         string    `json:"error,omitempty"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (s *Server) preferencesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+		if err != nil {
+			s.writeError(w, r, ErrInvalidUserID)
+			return
+		}
+
+		preferences, err := s.app.GetNotificationPreferences(r.Context(), userID)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		s.writeJSON(w, r, http.StatusOK, newPreferencesResponse(*preferences))
+	case http.MethodPut:
+		var request preferencesRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			s.writeError(w, r, ErrInvalidBody)
+			return
+		}
+
+		preferences := &storage.NotificationPreferences{
+			UserID:     request.UserID,
+			Email:      request.Email,
+			WebhookURL: request.WebhookURL,
+			QuietStart: request.QuietStart,
+			QuietEnd:   request.QuietEnd,
+			Timezone:   request.Timezone,
+		}
+		for _, channel := range request.Channels {
+			preferences.Channels = append(preferences.Channels, storage.Channel(channel))
+		}
+
+		if err := s.app.SetNotificationPreferences(r.Context(), preferences); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		s.writeJSON(w, r, http.StatusOK, newPreferencesResponse(*preferences))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) notificationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	userID, err := strconv.Atoi(query.Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	var limit int
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			s.writeError(w, r, ErrInvalidLimit)
+			return
+		}
+	}
+
+	notifications, err := s.app.ListNotifications(r.Context(), userID, limit)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := make([]notificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		response = append(response, notificationResponse{
+			ID:            notification.ID,
+			EventID:       notification.EventID,
+			UserID:        notification.UserID,
+			Title:         notification.Title,
+			Date:          notification.Date,
+			Channel:       string(notification.Channel),
+			Subject:       notification.Subject,
+			Body:          notification.Body,
+			Status:        string(notification.Status),
+			Attempts:      notification.Attempts,
+			Error:         notification.Error,
+			NextAttemptAt: notification.NextAttemptAt,
+			CreatedAt:     notification.CreatedAt,
+			UpdatedAt:     notification.UpdatedAt,
+		})
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
+
+func newPreferencesResponse(preferences storage.NotificationPreferences) preferencesResponse {
+	response := preferencesResponse{
+		UserID:     preferences.UserID,
+		Channels:   make([]string, 0, len(preferences.Channels)),
+		Email:      preferences.Email,
+		WebhookURL: preferences.WebhookURL,
+		QuietStart: preferences.QuietStart,
+		QuietEnd:   preferences.QuietEnd,
+		Timezone:   preferences.Timezone,
+		UpdatedAt:  preferences.UpdatedAt,
+	}
+	for _, channel := range preferences.Channels {
+		response.Channels = append(response.Channels, string(channel))
+	}
+	return response
+}
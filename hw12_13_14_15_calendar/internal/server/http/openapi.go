@@ -0,0 +1,422 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/health"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	adminSecurity  = "adminToken"
+)
+
+type parameter struct {
+	name        string
+	in          string
+	schema      map[string]any
+	required    bool
+	description string
+}
+
+type apiResponse struct {
+	description string
+	body        any
+	contentType string
+}
+
+// operation documents one method of a route; request and response bodies are
+// given as values of the Go types the handlers encode, so their schemas are
+// reflected from the same struct tags. Admin operations take the admin token.
+type operation struct {
+	method      string
+	route       string
+	summary     string
+	parameters  []parameter
+	body        any
+	responses   map[int]apiResponse
+	rateLimited bool
+	admin       bool
+}
+
+var (
+	integerSchema = map[string]any{"type": "integer"}
+	stringSchema  = map[string]any{"type": "string"}
+
+	userIDParam    = parameter{name: "user_id", in: "query", schema: integerSchema, required: true, description: "owner of the resource"} //nolint:lll
+	eventIDParam   = parameter{name: "id", in: "query", schema: integerSchema, required: true, description: "event id"}
+	webhookIDParam = parameter{name: "id", in: "query", schema: integerSchema, required: true, description: "webhook id"}
+	limitParam     = parameter{name: "limit", in: "query", schema: integerSchema, description: "page size"}
+	actorParam     = parameter{name: ActorHeader, in: "header", schema: stringSchema, description: "who performs the change, recorded in event history; only accepted from trusted proxies, otherwise the user is recorded"} //nolint:lll
+
+	noContent = map[int]apiResponse{http.StatusNoContent: {description: "done"}}
+)
+
+func ok(body any) map[int]apiResponse {
+	return map[int]apiResponse{http.StatusOK: {description: "success", body: body}}
+}
+
+var operations = []operation{
+	{
+		method: http.MethodGet, route: "/", summary: "Greeting",
+		responses: map[int]apiResponse{http.StatusOK: {description: "greeting", contentType: "text/plain"}},
+	},
+	{
+		method: http.MethodGet, route: "/events", summary: "List a page of events in a day, week or month",
+		parameters: []parameter{
+			userIDParam,
+			{name: "date", in: "query", schema: map[string]any{"type": "string", "format": "date"}, required: true},
+			{name: "range", in: "query", schema: map[string]any{"type": "string", "enum": []string{"day", "week", "month"}}, required: true}, //nolint:lll
+			{name: "cursor", in: "query", schema: stringSchema, description: "nextCursor of the previous page"},
+			limitParam,
+			{name: "If-None-Match", in: "header", schema: stringSchema, description: "ETag of a previous response"},
+		},
+		responses: map[int]apiResponse{
+			http.StatusOK:          {description: "success", body: eventsPageResponse{}},
+			http.StatusNotModified: {description: "events have not changed since the If-None-Match ETag"},
+		},
+		rateLimited: true,
+	},
+	{
+		method: http.MethodDelete, route: "/events", summary: "Move an event to trash",
+		parameters: []parameter{userIDParam, eventIDParam, actorParam}, responses: noContent, rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/events/trash", summary: "List deleted events",
+		parameters: []parameter{userIDParam}, responses: ok([]eventResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodPost, route: "/events/restore", summary: "Restore an event from trash",
+		parameters: []parameter{userIDParam, eventIDParam, actorParam}, responses: noContent, rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/events/history", summary: "List the revisions of an event",
+		parameters: []parameter{userIDParam, eventIDParam}, responses: ok([]revisionResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodPost, route: "/events/revert", summary: "Revert an event to an earlier revision",
+		parameters: []parameter{
+			userIDParam, eventIDParam, actorParam,
+			{name: "revision", in: "query", schema: integerSchema, required: true},
+		},
+		responses: ok(eventResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/events/changes",
+		summary: "Stream event changes as Server-Sent Events; every data line is a Change",
+		parameters: []parameter{
+			userIDParam,
+			{name: "after", in: "query", schema: integerSchema, description: "resume after this change id"},
+			{name: "Last-Event-ID", in: "header", schema: stringSchema, description: "resume after this change id"},
+		},
+		responses: map[int]apiResponse{
+			http.StatusOK: {description: "change stream", body: changeResponse{}, contentType: "text/event-stream"},
+		},
+		rateLimited: true,
+	},
+	{
+		method: http.MethodPost, route: "/events/batch",
+		summary:    "Create, update and delete events in one transaction, partially or all-or-nothing",
+		parameters: []parameter{userIDParam, actorParam}, body: batchRequest{},
+		responses: map[int]apiResponse{
+			http.StatusOK:                    {description: "results in request order", body: batchResponse{}},
+			http.StatusUnprocessableEntity:   {description: "atomic batch rolled back", body: batchResponse{}},
+			http.StatusRequestEntityTooLarge: {description: "body too large for a batch", body: errorResponse{}},
+		},
+		rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/events/reminders", summary: "List the reminders of an event",
+		parameters: []parameter{userIDParam, eventIDParam}, responses: ok([]reminderPayload{}), rateLimited: true,
+	},
+	{
+		method: http.MethodPut, route: "/events/reminders",
+		summary:    "Replace the reminders of an event; offsets are durations such as 24h or 10m before the start",
+		parameters: []parameter{userIDParam, eventIDParam},
+		body:       remindersRequest{}, responses: ok([]reminderPayload{}), rateLimited: true,
+	},
+	{
+		method: http.MethodPost, route: "/webhooks", summary: "Subscribe a URL to event changes",
+		body:        webhookRequest{},
+		responses:   map[int]apiResponse{http.StatusCreated: {description: "created, secret is only returned here", body: webhookResponse{}}}, //nolint:lll
+		rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/webhooks", summary: "List webhooks",
+		parameters: []parameter{userIDParam}, responses: ok([]webhookResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodDelete, route: "/webhooks", summary: "Delete a webhook",
+		parameters: []parameter{userIDParam, webhookIDParam}, responses: noContent, rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/webhooks/deliveries", summary: "List webhook deliveries, newest first",
+		parameters: []parameter{
+			userIDParam,
+			{name: "webhook_id", in: "query", schema: integerSchema},
+			limitParam,
+		},
+		responses: ok([]deliveryResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/notifications",
+		summary:    "List reminders newest first with their delivery status per channel",
+		parameters: []parameter{userIDParam, limitParam},
+		responses:  ok([]notificationResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/notifications/preferences", summary: "Show notification preferences",
+		parameters: []parameter{userIDParam}, responses: ok(preferencesResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodPut, route: "/notifications/preferences",
+		summary: "Set reminder channels (email, webhook, log), quiet hours (HH:MM) and timezone",
+		body:    preferencesRequest{}, responses: ok(preferencesResponse{}), rateLimited: true,
+	},
+	{
+		method: http.MethodGet, route: "/admin/log-level", summary: "Show log levels",
+		responses: ok(logLevelsResponse{}), rateLimited: true, admin: true,
+	},
+	{
+		method: http.MethodPut, route: "/admin/log-level", summary: "Set the root level or a package level",
+		body: logLevelRequest{}, responses: ok(logLevelsResponse{}), rateLimited: true, admin: true,
+	},
+	{
+		method: http.MethodDelete, route: "/admin/log-level", summary: "Drop a package level override",
+		parameters: []parameter{{name: "package", in: "query", schema: stringSchema, required: true}},
+		responses:  ok(logLevelsResponse{}), rateLimited: true, admin: true,
+	},
+	{
+		method: http.MethodGet, route: "/metrics", summary: "Prometheus metrics",
+		responses: map[int]apiResponse{http.StatusOK: {description: "metrics", contentType: "text/plain"}},
+	},
+	{
+		method: http.MethodGet, route: "/healthz", summary: "Liveness",
+		responses: ok(health.Report{}),
+	},
+	{
+		method: http.MethodGet, route: "/readyz", summary: "Readiness with per-check detail",
+		responses: map[int]apiResponse{
+			http.StatusOK:                 {description: "ready", body: health.Report{}},
+			http.StatusServiceUnavailable: {description: "a check failed", body: health.Report{}},
+		},
+	},
+	{
+		method: http.MethodGet, route: "/openapi.json", summary: "This document",
+		responses: map[int]apiResponse{http.StatusOK: {description: "OpenAPI document"}},
+	},
+}
+
+// OpenAPI builds the OpenAPI 3 document for every route of the server.
+func OpenAPI() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]map[string]any{}
+
+	for _, op := range operations {
+		if paths[op.route] == nil {
+			paths[op.route] = map[string]any{}
+		}
+		paths[op.route][strings.ToLower(op.method)] = op.document(schemas)
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":   "Calendar API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				adminSecurity: map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func (op operation) document(schemas map[string]any) map[string]any {
+	doc := map[string]any{
+		"summary":     op.summary,
+		"operationId": operationID(op.method, op.route),
+	}
+
+	if len(op.parameters) > 0 {
+		parameters := make([]map[string]any, 0, len(op.parameters))
+		for _, p := range op.parameters {
+			param := map[string]any{"name": p.name, "in": p.in, "schema": p.schema, "required": p.required}
+			if p.description != "" {
+				param["description"] = p.description
+			}
+			parameters = append(parameters, param)
+		}
+		doc["parameters"] = parameters
+	}
+
+	if op.body != nil {
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(op.body), schemas)},
+			},
+		}
+	}
+
+	responses := map[string]any{}
+	for status, response := range op.responses {
+		responses[strconv.Itoa(status)] = response.document(schemas)
+	}
+
+	errorBody := apiResponse{description: "error", body: errorResponse{}}
+	if len(op.parameters) > 0 || op.body != nil {
+		responses[strconv.Itoa(http.StatusBadRequest)] = errorBody.document(schemas)
+	}
+	if op.rateLimited {
+		limited := errorBody.document(schemas)
+		limited["description"] = "rate limited, retry after the Retry-After header"
+		limited["headers"] = map[string]any{"Retry-After": map[string]any{"schema": integerSchema}}
+		responses[strconv.Itoa(http.StatusTooManyRequests)] = limited
+		responses[strconv.Itoa(http.StatusInternalServerError)] = errorBody.document(schemas)
+	}
+	if op.admin {
+		doc["security"] = []map[string]any{{adminSecurity: []string{}}}
+
+		unauthorized := errorBody.document(schemas)
+		unauthorized["description"] = "missing or invalid admin token"
+		responses[strconv.Itoa(http.StatusUnauthorized)] = unauthorized
+		disabled := errorBody.document(schemas)
+		disabled["description"] = "no admin token is configured"
+		responses[strconv.Itoa(http.StatusForbidden)] = disabled
+	}
+	doc["responses"] = responses
+
+	return doc
+}
+
+func (r apiResponse) document(schemas map[string]any) map[string]any {
+	doc := map[string]any{"description": r.description}
+
+	contentType := r.contentType
+	if contentType == "" && r.body != nil {
+		contentType = "application/json"
+	}
+	if contentType != "" {
+		media := map[string]any{}
+		if r.body != nil {
+			media["schema"] = schemaOf(reflect.TypeOf(r.body), schemas)
+		} else {
+			media["schema"] = stringSchema
+		}
+		doc["content"] = map[string]any{contentType: media}
+	}
+
+	return doc
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf reflects a JSON schema from the encoding/json view of t; named
+// structs become components referenced by name.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaOf(t.Elem(), schemas)
+		if _, ref := schema["$ref"]; ref {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, done := schemas[name]; !done {
+			schemas[name] = nil
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := map[string]any{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaOf(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// schemaName turns eventResponse into Event and webhookRequest into WebhookRequest.
+func schemaName(t reflect.Type) string {
+	name := strings.TrimSuffix(t.Name(), "Response")
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func operationID(method, route string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(route, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		id += string(runes)
+	}
+	return id
+}
+
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusOK, OpenAPI())
+}
@@ -0,0 +1,79 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/health"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	calendar := app.New(logg, storageService, config.AppConf{
+		Pagination: config.PaginationConf{Secret: "test", DefaultSize: 50, MaxSize: 500},
+	})
+
+	server, err := NewServer(logg, calendar, config.HTTPConf{}, metrics.New(), health.New(0), logg)
+	require.NoError(t, err)
+	return server
+}
+
+func TestOpenAPI(t *testing.T) {
+	server := newTestServer(t)
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var document struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	require.Equal(t, openAPIVersion, document.OpenAPI)
+
+	t.Run("Every Route Documented", func(t *testing.T) {
+		documented := make([]string, 0, len(document.Paths))
+		for route := range document.Paths {
+			documented = append(documented, route)
+		}
+		require.ElementsMatch(t, server.routes, documented)
+	})
+
+	t.Run("Schema References Resolve", func(t *testing.T) {
+		body := recorder.Body.String()
+		for _, part := range strings.Split(body, `"$ref":"#/components/schemas/`)[1:] {
+			name, _, _ := strings.Cut(part, `"`)
+			require.Contains(t, document.Components.Schemas, name)
+		}
+	})
+
+	t.Run("Event Schema", func(t *testing.T) {
+		var schema struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(document.Components.Schemas["Event"], &schema))
+		require.Equal(t, []string{"date", "duration", "id", "title", "userId"}, schema.Required)
+		require.JSONEq(t, `{"type":"string","format":"date-time","nullable":true}`, string(schema.Properties["deletedAt"]))
+	})
+}
@@ -0,0 +1,87 @@
+package internalhttp
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/ratelimit"
+)
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// rateLimits keeps one limiter per route, built from the default limit and route overrides.
+type rateLimits struct {
+	mu       sync.RWMutex
+	conf     config.RateLimitConf
+	limiters map[string]*ratelimit.Limiter
+}
+
+func newRateLimits(conf config.RateLimitConf) *rateLimits {
+	return &rateLimits{conf: conf, limiters: make(map[string]*ratelimit.Limiter)}
+}
+
+func (l *rateLimits) limitFor(route string) ratelimit.Limit {
+	if !l.conf.Enabled {
+		return ratelimit.Limit{}
+	}
+
+	if override, ok := l.conf.Routes[route]; ok {
+		return ratelimit.Limit{Rate: override.Rate, Burst: override.Burst}
+	}
+	return ratelimit.Limit{Rate: l.conf.Rate, Burst: l.conf.Burst}
+}
+
+func (l *rateLimits) limiter(route string) *ratelimit.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[route]
+	if !ok {
+		limiter = ratelimit.New(l.limitFor(route))
+		l.limiters[route] = limiter
+	}
+	return limiter
+}
+
+// Update applies new limits to every route without resetting their buckets.
+func (l *rateLimits) Update(conf config.RateLimitConf) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.conf = conf
+	for route, limiter := range l.limiters {
+		limiter.SetLimit(l.limitFor(route))
+	}
+}
+
+// rateLimitMiddleware spends a token from the client IP's bucket and, when the
+// request names a user, from that user's bucket too.
+func (s *Server) rateLimitMiddleware(route string, next http.Handler) http.Handler {
+	limiter := s.rateLimits.limiter(route)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := limiter.Allow("ip:" + clientIP(r))
+		if ok {
+			if userID := r.URL.Query().Get("user_id"); userID != "" {
+				ok, wait = limiter.Allow("user:" + userID)
+			}
+		}
+
+		if !ok {
+			w.Header().Set("Retry-After", retryAfter(wait))
+			s.writeError(w, r, ErrRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,93 @@
+package internalhttp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadUserIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		realIP    string
+		expected  string
+	}{
+		{name: "direct", remote: "203.0.113.7:5000", expected: "203.0.113.7"},
+		{name: "spoofed from untrusted", remote: "203.0.113.7:5000", forwarded: "1.1.1.1", expected: "203.0.113.7"},
+		{name: "via trusted proxy", remote: "10.0.0.2:80", forwarded: "198.51.100.4", expected: "198.51.100.4"},
+		{
+			name: "client prepended fake hop", remote: "10.0.0.2:80",
+			forwarded: "1.1.1.1, 198.51.100.4, 192.168.1.1", expected: "198.51.100.4",
+		},
+		{name: "real ip from trusted", remote: "192.168.1.1:80", realIP: "198.51.100.9", expected: "198.51.100.9"},
+		{name: "garbage header", remote: "10.0.0.2:80", forwarded: "unknown", expected: "10.0.0.2"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tc.remote
+			if tc.forwarded != "" {
+				request.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if tc.realIP != "" {
+				request.Header.Set("X-Real-Ip", tc.realIP)
+			}
+
+			require.Equal(t, tc.expected, ReadUserIP(request, trusted))
+		})
+	}
+
+	_, err = ParseTrustedProxies([]string{"not-an-ip"})
+	require.ErrorIs(t, err, ErrInvalidTrustedProxy)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := &Server{
+		logger: &recordingLogger{},
+		rateLimits: newRateLimits(config.RateLimitConf{
+			Enabled: true,
+			Rate:    1,
+			Burst:   2,
+			Routes:  map[string]config.RouteLimitConf{"/open": {Rate: 0}},
+		}),
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	limited := clientIPMiddleware(nil, server.rateLimitMiddleware("/events", ok))
+	open := clientIPMiddleware(nil, server.rateLimitMiddleware("/open", ok))
+
+	call := func(handler http.Handler, target string, remote string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		request.RemoteAddr = net.JoinHostPort(remote, "1234")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusNoContent, call(limited, "/events?user_id=1", "198.51.100.1").Code)
+	require.Equal(t, http.StatusNoContent, call(limited, "/events?user_id=1", "198.51.100.2").Code)
+
+	response := call(limited, "/events?user_id=1", "198.51.100.3")
+	require.Equal(t, http.StatusTooManyRequests, response.Code, "user bucket is shared across addresses")
+	require.Equal(t, "1", response.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusNoContent, call(limited, "/events?user_id=2", "198.51.100.3").Code)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusNoContent, call(open, "/open", "198.51.100.1").Code)
+	}
+
+	server.rateLimits.Update(config.RateLimitConf{Enabled: false})
+	require.Equal(t, http.StatusNoContent, call(limited, "/events?user_id=1", "198.51.100.3").Code)
+}
@@ -0,0 +1,71 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var ErrInvalidReminderOffset = errors.New("invalid reminder offset, expected a duration such as 1h30m")
+
+// reminder offsets travel as Go duration strings, e.g. "24h" or "10m0s".
+type reminderPayload struct {
+	Offset  string `json:"offset"`
+	Channel string `json:"channel,omitempty"`
+}
+
+type remindersRequest struct {
+	Reminders []reminderPayload `json:"reminders"`
+}
+
+func (s *Server) remindersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, id, err := eventKey(r)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var request remindersRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			s.writeError(w, r, ErrInvalidBody)
+			return
+		}
+
+		reminders := make([]storage.Reminder, 0, len(request.Reminders))
+		for _, payload := range request.Reminders {
+			offset, err := time.ParseDuration(payload.Offset)
+			if err != nil {
+				s.writeError(w, r, ErrInvalidReminderOffset)
+				return
+			}
+			reminders = append(reminders, storage.Reminder{Offset: offset, Channel: storage.Channel(payload.Channel)})
+		}
+
+		if err := s.app.SetReminders(r.Context(), id, userID, reminders); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	reminders, err := s.app.ListReminders(r.Context(), id, userID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := make([]reminderPayload, 0, len(reminders))
+	for _, reminder := range reminders {
+		response = append(response, reminderPayload{Offset: reminder.Offset.String(), Channel: string(reminder.Channel)})
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
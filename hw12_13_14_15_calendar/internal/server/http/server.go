@@ -3,11 +3,11 @@ package internalhttp
 import (
 	"context"
 	"errors"
-	"log"
 	"net"
 	"net/http"
 	"time"
 
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
 	storage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
 )
@@ -20,6 +20,22 @@ type Server struct {
 	httpServer *http.Server
 	logger     Logger
 	app        Application
+	metrics    Metrics
+	health     Health
+	logLevels  LogLevels
+	adminToken string
+	rateLimits *rateLimits
+	mux        *http.ServeMux
+	routes     []string
+	// closing is canceled when shutdown starts, ending the change streams
+	// that would otherwise keep the drain waiting until its deadline.
+	closing      context.Context
+	closeStreams context.CancelFunc
+}
+
+type Metrics interface {
+	ObserveHTTP(route, method string, status int, duration time.Duration)
+	Handler() http.Handler
 }
 
 type Logger interface {
@@ -27,62 +43,147 @@ type Logger interface {
 	Error(msg string, attrs ...any)
 	Debug(msg string, attrs ...any)
 	Warn(msg string, attrs ...any)
+	InfoContext(ctx context.Context, msg string, attrs ...any)
+	ErrorContext(ctx context.Context, msg string, attrs ...any)
 }
 
 type Application interface {
 	CreateEvent(ctx context.Context, event *storage.Event) error
 	GetEventsForRange(ctx context.Context, userID int, dateFrom time.Time, dateRange int) ([]storage.Event, error)
+	GetEventsPage(
+		ctx context.Context, userID int, dateFrom time.Time, dateRange int, cursor string, limit int,
+	) (*app.EventsPage, error)
+	StreamChanges(ctx context.Context, userID int, after int64, send func(change storage.Change) error) error
+	DeleteEvent(ctx context.Context, id int, userID int) error
+	ListTrash(ctx context.Context, userID int) ([]storage.Event, error)
+	RestoreEvent(ctx context.Context, id int, userID int) error
+	EventHistory(ctx context.Context, id int, userID int) ([]storage.Revision, error)
+	RevertEvent(ctx context.Context, id int, userID int, revision int) (*storage.Event, error)
+	CreateWebhook(ctx context.Context, webhook *storage.Webhook) error
+	ListWebhooks(ctx context.Context, userID int) ([]storage.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int, userID int) error
+	ListWebhookDeliveries(ctx context.Context, userID int, webhookID int, limit int) ([]storage.WebhookDelivery, error)
+	SetNotificationPreferences(ctx context.Context, preferences *storage.NotificationPreferences) error
+	GetNotificationPreferences(ctx context.Context, userID int) (*storage.NotificationPreferences, error)
+	ListNotifications(ctx context.Context, userID int, limit int) ([]storage.Notification, error)
+	SetReminders(ctx context.Context, eventID int, userID int, reminders []storage.Reminder) error
+	ListReminders(ctx context.Context, eventID int, userID int) ([]storage.Reminder, error)
+	ApplyBatch(ctx context.Context, items []storage.BatchItem, atomic bool) ([]storage.BatchResult, error)
 }
 
-func NewServer(logger Logger, app Application, config config.HTTPConf) *Server {
-	addr := net.JoinHostPort(config.Host, config.Port)
-	httpServer := &http.Server{Addr: addr, ReadHeaderTimeout: Timeout * time.Second}
+func NewServer(
+	logger Logger, app Application, config config.HTTPConf, metrics Metrics, health Health, logLevels LogLevels,
+) (*Server, error) {
+	trustedProxies, err := ParseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
-	http.Handle("/", loggingMiddleware(http.HandlerFunc(helloHandler)))
+	mux := http.NewServeMux()
+	addr := net.JoinHostPort(config.Host, config.Port)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           requestIDMiddleware(clientIPMiddleware(trustedProxies, actorMiddleware(trustedProxies, mux))),
+		ReadHeaderTimeout: Timeout * time.Second,
+	}
 
-	return &Server{
+	server := &Server{
 		logger:     logger,
 		app:        app,
+		metrics:    metrics,
+		health:     health,
+		logLevels:  logLevels,
+		adminToken: config.AdminToken,
+		rateLimits: newRateLimits(config.RateLimit),
+		mux:        mux,
 		httpServer: httpServer,
 	}
+	if config.TLS.Enabled {
+		if config.TLS.SelfSigned {
+			if err := ensureSelfSigned(logger, config.TLS, config.Host); err != nil {
+				return nil, err
+			}
+		}
+
+		certificates, err := newCertificates(logger, config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		httpServer.TLSConfig = certificates.config()
+	}
+
+	server.closing, server.closeStreams = context.WithCancel(context.Background())
+	httpServer.RegisterOnShutdown(server.closeStreams)
+
+	server.handle("/", helloHandler)
+	server.handle("/events", server.eventsHandler)
+	server.handle("/events/trash", server.trashHandler)
+	server.handle("/events/restore", server.restoreHandler)
+	server.handle("/events/history", server.historyHandler)
+	server.handle("/events/revert", server.revertHandler)
+	server.handle("/events/changes", server.changesHandler)
+	server.handle("/events/reminders", server.remindersHandler)
+	server.handle("/events/batch", server.batchHandler)
+	server.handle("/webhooks", server.webhooksHandler)
+	server.handle("/webhooks/deliveries", server.webhookDeliveriesHandler)
+	server.handle("/notifications", server.notificationsHandler)
+	server.handle("/notifications/preferences", server.preferencesHandler)
+	server.handle("/admin/log-level", server.requireAdmin(server.logLevelHandler))
+	server.mount("/metrics", metrics.Handler())
+	server.mount("/healthz", http.HandlerFunc(server.livenessHandler))
+	server.mount("/readyz", http.HandlerFunc(server.readinessHandler))
+	server.mount("/openapi.json", http.HandlerFunc(server.openAPIHandler))
+
+	return server, nil
 }
 
-func (s *Server) Start(ctx context.Context) error {
-	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-		return err
+func (s *Server) handle(route string, handlerFunc http.HandlerFunc) {
+	var handler http.Handler = tracingMiddleware(route, handlerFunc)
+	handler = s.rateLimitMiddleware(route, handler)
+	handler = metricsMiddleware(s.metrics, route, handler)
+	handler = loggingMiddleware(s.logger, route, handler)
+
+	s.mount(route, handler)
+}
+
+// mount registers a route without the per-request middleware chain.
+func (s *Server) mount(route string, handler http.Handler) {
+	s.routes = append(s.routes, route)
+	s.mux.Handle(route, handler)
+}
+
+// Handler returns the full handler chain, e.g. for serving from httptest.
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+func (s *Server) UpdateRateLimits(conf config.RateLimitConf) {
+	s.rateLimits.Update(conf)
+}
+
+// Start serves until Stop is called, which makes it return nil. With TLS
+// configured it serves HTTPS, negotiating HTTP/2 with clients that support it.
+func (s *Server) Start(_ context.Context) error {
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = s.httpServer.ListenAndServe()
 	}
 
-	<-ctx.Done()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
 	return nil
 }
 
+// Stop stops accepting connections and waits for in-flight requests until ctx is done.
 func (s *Server) Stop(ctx context.Context) error {
 	err := s.httpServer.Shutdown(ctx)
 	return err
 }
 
-func helloHandler(w http.ResponseWriter, r *http.Request) {
-	ip := ReadUserIP(r)
-	log.Printf(
-		"%v [%v] %v %v %v %v %v %v",
-		ip,
-		time.Now().Format("02/Jan/2006:15:04:05 -0700"),
-		r.Method,
-		r.URL.Path,
-		r.Proto,
-		200,
-		100,
-		r.UserAgent(),
-	)
-}
-
-func ReadUserIP(r *http.Request) string {
-	IPAddress := r.Header.Get("X-Real-Ip")
-	if IPAddress == "" {
-		IPAddress = r.Header.Get("X-Forwarded-For")
-	}
-	if IPAddress == "" {
-		IPAddress = r.RemoteAddr
-	}
-	return IPAddress
+func helloHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("Hello from calendar\n"))
 }
@@ -0,0 +1,232 @@
+package internalhttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+)
+
+const selfSignedValidity = 365 * 24 * time.Hour
+
+var ErrNoClientCAs = errors.New("client CA file holds no PEM certificates")
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.VerifyClientCertIfGiven,
+	"require":  tls.RequireAndVerifyClientCert,
+}
+
+// certificates serves the configured key pair and client CAs, reloading them
+// once the files change. A broken update is logged and the previous files stay
+// in use, so a half-written rotation never takes the server down.
+type certificates struct {
+	logger     Logger
+	conf       config.TLSConf
+	clientAuth tls.ClientAuthType
+	now        func() time.Time
+
+	mu        sync.Mutex
+	checked   time.Time
+	modified  time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func newCertificates(logger Logger, conf config.TLSConf) (*certificates, error) {
+	c := &certificates{
+		logger:     logger,
+		conf:       conf,
+		clientAuth: clientAuthTypes[strings.ToLower(conf.ClientAuth)],
+		now:        time.Now,
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	c.checked = c.now()
+
+	return c, nil
+}
+
+// config returns the server TLS config. Every handshake gets a config built
+// from the current files, which is how a rotated client CA takes effect.
+func (c *certificates) config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := c.current()
+			return cert, nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := c.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   c.clientAuth,
+				ClientCAs:    clientCAs,
+			}, nil
+		},
+	}
+}
+
+func (c *certificates) current() (*tls.Certificate, *x509.CertPool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now := c.now(); now.Sub(c.checked) >= c.conf.ReloadInterval {
+		c.checked = now
+
+		modified, err := c.modTime()
+		switch {
+		case err != nil:
+			c.logger.Error("failed to check tls files, keeping the loaded certificate: " + err.Error())
+		case !modified.Equal(c.modified):
+			if err := c.load(); err != nil {
+				c.logger.Error("failed to reload tls files, keeping the loaded certificate: " + err.Error())
+			} else {
+				c.logger.Info("reloaded tls certificate", "cert", c.conf.CertFile)
+			}
+		}
+	}
+
+	return c.cert, c.clientCAs
+}
+
+func (c *certificates) load() error {
+	modified, err := c.modTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.conf.CertFile, c.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if c.conf.ClientCAFile != "" {
+		data, err := os.ReadFile(c.conf.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("%w: %s", ErrNoClientCAs, c.conf.ClientCAFile)
+		}
+	}
+
+	c.cert, c.clientCAs, c.modified = &cert, clientCAs, modified
+	return nil
+}
+
+// modTime is the latest modification time of the files, so that a change to
+// any of them triggers a reload.
+func (c *certificates) modTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{c.conf.CertFile, c.conf.KeyFile, c.conf.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// ensureSelfSigned writes a self-signed certificate for local development
+// unless both files already exist.
+func ensureSelfSigned(logger Logger, conf config.TLSConf, host string) error {
+	_, certErr := os.Stat(conf.CertFile)
+	_, keyErr := os.Stat(conf.KeyFile)
+	if certErr == nil && keyErr == nil {
+		return nil
+	}
+
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+		hosts = append(hosts, host)
+	}
+
+	if err := writeSelfSigned(conf.CertFile, conf.KeyFile, hosts); err != nil {
+		return fmt.Errorf("generate self-signed certificate: %w", err)
+	}
+
+	logger.Warn("generated a self-signed tls certificate, do not use it in production",
+		"cert", conf.CertFile, "hosts", strings.Join(hosts, ","))
+	return nil
+}
+
+func writeSelfSigned(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"calendar development"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der)
+}
+
+func writePEM(path, blockType string, der []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+}
@@ -0,0 +1,125 @@
+package internalhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/health"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// startTLS serves conf on a free local port and returns the base URL.
+func startTLS(t *testing.T, conf config.TLSConf) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, listener.Close())
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	server, err := NewServer(logg, app.New(logg, storageService, config.AppConf{}),
+		config.HTTPConf{Host: "127.0.0.1", Port: port, TLS: conf}, metrics.New(), health.New(0), logg)
+	require.NoError(t, err)
+
+	go func() { _ = server.Start(context.Background()) }()
+	t.Cleanup(func() { _ = server.Stop(context.Background()) })
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	return "https://" + net.JoinHostPort("localhost", port)
+}
+
+func tlsClient(t *testing.T, caFile string, certs ...tls.Certificate) *http.Client {
+	t.Helper()
+
+	ca, err := os.ReadFile(caFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(ca))
+
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12},
+		ForceAttemptHTTP2: true,
+	}}
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.TLSConf{
+		Enabled:        true,
+		CertFile:       filepath.Join(dir, "certs", "server.pem"),
+		KeyFile:        filepath.Join(dir, "certs", "server-key.pem"),
+		ClientAuth:     "none",
+		SelfSigned:     true,
+		ReloadInterval: time.Millisecond,
+	}
+	baseURL := startTLS(t, conf)
+
+	get := func(client *http.Client) (*http.Response, error) {
+		response, err := client.Get(baseURL + "/healthz")
+		if err == nil {
+			response.Body.Close()
+		}
+		return response, err
+	}
+
+	response, err := get(tlsClient(t, conf.CertFile))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "HTTP/2.0", response.Proto)
+	served := response.TLS.PeerCertificates[0].SerialNumber
+
+	t.Run("Reload On Change", func(t *testing.T) {
+		require.NoError(t, writeSelfSigned(conf.CertFile, conf.KeyFile, []string{"localhost"}))
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(conf.CertFile, later, later))
+
+		response, err := get(tlsClient(t, conf.CertFile))
+		require.NoError(t, err)
+		require.NotEqual(t, served, response.TLS.PeerCertificates[0].SerialNumber)
+	})
+
+	t.Run("Mutual TLS", func(t *testing.T) {
+		clientCert := filepath.Join(dir, "client.pem")
+		clientKey := filepath.Join(dir, "client-key.pem")
+		require.NoError(t, writeSelfSigned(clientCert, clientKey, []string{"tests"}))
+
+		mutual := conf
+		mutual.ClientAuth = "require"
+		mutual.ClientCAFile = clientCert
+		baseURL = startTLS(t, mutual)
+
+		_, err := get(tlsClient(t, conf.CertFile))
+		require.Error(t, err, "client certificate required")
+
+		pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+		response, err := get(tlsClient(t, conf.CertFile, pair))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+	})
+}
@@ -0,0 +1,198 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var (
+	ErrInvalidBody      = errors.New("invalid request body")
+	ErrInvalidWebhookID = errors.New("invalid webhook id")
+)
+
+type webhookRequest struct {
+	UserID int      `json:"userId"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+type webhookResponse struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type deliveryResponse struct {
+	ID            int             `json:"id"`
+	WebhookID     int             `json:"webhookId"`
+	EventID       int             `json:"eventId"`
+	Type          string          `json:"type"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"responseCode,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+func (s *Server) webhooksHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.createWebhook(w, r)
+	case http.MethodGet:
+		s.listWebhooks(w, r)
+	case http.MethodDelete:
+		s.deleteWebhook(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var request webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.writeError(w, r, ErrInvalidBody)
+		return
+	}
+
+	webhook := &storage.Webhook{UserID: request.UserID, URL: request.URL, Secret: request.Secret}
+	for _, event := range request.Events {
+		webhook.Events = append(webhook.Events, storage.ChangeType(event))
+	}
+
+	if err := s.app.CreateWebhook(r.Context(), webhook); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// The secret is only revealed once, right after the subscription is created.
+	response := newWebhookResponse(*webhook)
+	response.Secret = webhook.Secret
+	s.writeJSON(w, r, http.StatusCreated, response)
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	webhooks, err := s.app.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, newWebhookResponse(webhook))
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := strconv.Atoi(query.Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	id, err := strconv.Atoi(query.Get("id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidWebhookID)
+		return
+	}
+
+	if err := s.app.DeleteWebhook(r.Context(), id, userID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) webhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	userID, err := strconv.Atoi(query.Get("user_id"))
+	if err != nil {
+		s.writeError(w, r, ErrInvalidUserID)
+		return
+	}
+
+	var webhookID, limit int
+	if raw := query.Get("webhook_id"); raw != "" {
+		webhookID, err = strconv.Atoi(raw)
+		if err != nil {
+			s.writeError(w, r, ErrInvalidWebhookID)
+			return
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			s.writeError(w, r, ErrInvalidLimit)
+			return
+		}
+	}
+
+	deliveries, err := s.app.ListWebhookDeliveries(r.Context(), userID, webhookID, limit)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	response := make([]deliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, deliveryResponse{
+			ID:            delivery.ID,
+			WebhookID:     delivery.WebhookID,
+			EventID:       delivery.EventID,
+			Type:          string(delivery.Type),
+			Status:        string(delivery.Status),
+			Attempts:      delivery.Attempts,
+			ResponseCode:  delivery.ResponseCode,
+			Error:         delivery.Error,
+			Payload:       delivery.Payload,
+			NextAttemptAt: delivery.NextAttemptAt,
+			CreatedAt:     delivery.CreatedAt,
+			UpdatedAt:     delivery.UpdatedAt,
+		})
+	}
+
+	s.writeJSON(w, r, http.StatusOK, response)
+}
+
+func newWebhookResponse(webhook storage.Webhook) webhookResponse {
+	response := webhookResponse{
+		ID:        webhook.ID,
+		UserID:    webhook.UserID,
+		URL:       webhook.URL,
+		Events:    make([]string, 0, len(webhook.Events)),
+		CreatedAt: webhook.CreatedAt,
+	}
+	for _, event := range webhook.Events {
+		response.Events = append(response.Events, string(event))
+	}
+	return response
+}
@@ -0,0 +1,22 @@
+package storage
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+// BatchItem is one operation of a batch; a delete only reads Event.ID and Event.UserID.
+type BatchItem struct {
+	Op    BatchOp
+	Event Event
+}
+
+// BatchResult is the outcome of the item at the same index: the stored event
+// on success, otherwise the error that item failed with.
+type BatchResult struct {
+	Event Event
+	Err   error
+}
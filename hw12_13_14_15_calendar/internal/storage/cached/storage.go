@@ -0,0 +1,276 @@
+package cachedstorage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const cacheName = "events"
+
+type Metrics interface {
+	ObserveCache(cache string, hit bool)
+}
+
+// Storage serves event listings from a bounded in-memory cache and passes
+// every other call through. A write for a user invalidates all of that user's
+// listings, but only in this process: writes through another replica or the
+// admin tool become visible once the cached entries expire after the TTL. It
+// is only safe to enable with a single replica and no concurrent admin writes.
+type Storage struct {
+	app.StorageService
+	metrics Metrics
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+
+	mu    sync.Mutex
+	users map[int]*user
+	// entries map keys to elements of lru.
+	entries map[key]*list.Element
+	// lru holds *entry values, most recently used first.
+	lru *list.List
+}
+
+// user tracks the cache state of one user; it is dropped once the user has no
+// entries and no loads in flight, the only holders of its generation.
+type user struct {
+	// generation is bumped on every write for the user. It is part of the key,
+	// so a write makes the user's older entries unreachable at once, including
+	// ones a concurrent read stores after the write.
+	generation uint64
+	entries    int
+	loads      int
+}
+
+type key struct {
+	userID     int
+	generation uint64
+	dateFrom   int64
+	dateTo     int64
+	page       bool
+	afterDate  int64
+	afterID    int
+	limit      int
+}
+
+type entry struct {
+	key     key
+	events  []storage.Event
+	expires time.Time
+}
+
+func New(next app.StorageService, conf config.CacheConf, metrics Metrics) *Storage {
+	return &Storage{
+		StorageService: next,
+		metrics:        metrics,
+		ttl:            conf.TTL,
+		size:           conf.Size,
+		now:            time.Now,
+		users:          make(map[int]*user),
+		entries:        make(map[key]*list.Element),
+		lru:            list.New(),
+	}
+}
+
+func (s *Storage) ListEvents(
+	ctx context.Context, userID int, dateFrom time.Time, dateTo time.Time,
+) ([]storage.Event, error) {
+	k := s.key(userID)
+	k.dateFrom, k.dateTo = dateFrom.UnixNano(), dateTo.UnixNano()
+
+	return s.lookup(k, func() ([]storage.Event, error) {
+		return s.StorageService.ListEvents(ctx, userID, dateFrom, dateTo)
+	})
+}
+
+func (s *Storage) ListEventsPage(ctx context.Context, query storage.ListQuery) ([]storage.Event, error) {
+	k := s.key(query.UserID)
+	k.dateFrom, k.dateTo = query.DateFrom.UnixNano(), query.DateTo.UnixNano()
+	k.page, k.limit = true, query.Limit
+	if query.After != nil {
+		k.afterDate, k.afterID = query.After.Date.UnixNano(), query.After.ID
+	}
+
+	return s.lookup(k, func() ([]storage.Event, error) {
+		return s.StorageService.ListEventsPage(ctx, query)
+	})
+}
+
+func (s *Storage) AddEvent(ctx context.Context, event *storage.Event) error {
+	defer s.invalidate(event.UserID)
+	return s.StorageService.AddEvent(ctx, event)
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, updated *storage.Event) error {
+	defer s.invalidate(updated.UserID)
+	return s.StorageService.UpdateEvent(ctx, updated)
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id int, userID int) error {
+	defer s.invalidate(userID)
+	return s.StorageService.DeleteEvent(ctx, id, userID)
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id int, userID int, deletedAfter time.Time) error {
+	defer s.invalidate(userID)
+	return s.StorageService.RestoreEvent(ctx, id, userID, deletedAfter)
+}
+
+func (s *Storage) RevertEvent(ctx context.Context, id int, userID int, revision int) (*storage.Event, error) {
+	defer s.invalidate(userID)
+	return s.StorageService.RevertEvent(ctx, id, userID, revision)
+}
+
+func (s *Storage) ApplyBatch(
+	ctx context.Context, items []storage.BatchItem, atomic bool,
+) ([]storage.BatchResult, error) {
+	users := make([]int, 0, len(items))
+	for _, item := range items {
+		users = append(users, item.Event.UserID)
+	}
+	defer s.invalidate(users...)
+
+	return s.StorageService.ApplyBatch(ctx, items, atomic)
+}
+
+// key starts a cache key at the user's current generation. It must be taken
+// before querying the storage so that a write racing the query is not cached,
+// and released once the lookup is done.
+func (s *Storage) key(userID int) key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.users[userID]
+	if u == nil {
+		u = &user{}
+		s.users[userID] = u
+	}
+	u.loads++
+
+	return key{userID: userID, generation: u.generation}
+}
+
+func (s *Storage) release(k key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.users[k.userID]
+	u.loads--
+	s.prune(k.userID, u)
+}
+
+// prune drops the state of a user nothing refers to any more.
+func (s *Storage) prune(userID int, u *user) {
+	if u.entries == 0 && u.loads == 0 {
+		delete(s.users, userID)
+	}
+}
+
+func (s *Storage) lookup(k key, load func() ([]storage.Event, error)) ([]storage.Event, error) {
+	defer s.release(k)
+
+	if events, ok := s.get(k); ok {
+		s.metrics.ObserveCache(cacheName, true)
+		return events, nil
+	}
+	s.metrics.ObserveCache(cacheName, false)
+
+	events, err := load()
+	if err != nil {
+		return nil, err
+	}
+	s.put(k, events)
+
+	return events, nil
+}
+
+func (s *Storage) get(k key) ([]storage.Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[k]
+	if !ok {
+		return nil, false
+	}
+
+	cached := element.Value.(*entry)
+	if !s.now().Before(cached.expires) {
+		s.remove(element)
+		return nil, false
+	}
+	s.lru.MoveToFront(element)
+
+	return copyEvents(cached.events), true
+}
+
+func (s *Storage) put(k key, events []storage.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.users[k.userID]
+	if k.generation != u.generation {
+		return
+	}
+
+	cached := &entry{key: k, events: copyEvents(events), expires: s.now().Add(s.ttl)}
+	if element, ok := s.entries[k]; ok {
+		element.Value = cached
+		s.lru.MoveToFront(element)
+		return
+	}
+
+	s.entries[k] = s.lru.PushFront(cached)
+	u.entries++
+	for s.lru.Len() > s.size {
+		s.remove(s.lru.Back())
+	}
+}
+
+// invalidate moves the users to a new generation and drops their entries.
+func (s *Storage) invalidate(userIDs ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stale := make(map[int]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if u := s.users[userID]; u != nil && !stale[userID] {
+			stale[userID] = true
+			u.generation++
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	for element := s.lru.Front(); element != nil; {
+		next := element.Next()
+		if stale[element.Value.(*entry).key.userID] {
+			s.remove(element)
+		}
+		element = next
+	}
+}
+
+func (s *Storage) remove(element *list.Element) {
+	k := element.Value.(*entry).key
+	delete(s.entries, k)
+	s.lru.Remove(element)
+
+	u := s.users[k.userID]
+	u.entries--
+	s.prune(k.userID, u)
+}
+
+// copyEvents keeps callers from mutating cached listings.
+func copyEvents(events []storage.Event) []storage.Event {
+	if events == nil {
+		return nil
+	}
+	return append(make([]storage.Event, 0, len(events)), events...)
+}
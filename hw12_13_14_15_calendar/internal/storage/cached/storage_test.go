@@ -0,0 +1,128 @@
+package cachedstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMetrics struct {
+	hits, misses int
+}
+
+func (m *fakeMetrics) ObserveCache(_ string, hit bool) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	newStorage := func(t *testing.T, size int) (*Storage, *fakeMetrics, *time.Time) {
+		t.Helper()
+
+		backend, err := memorystorage.New()
+		require.NoError(t, err)
+
+		metrics := &fakeMetrics{}
+		cached := New(backend, config.CacheConf{Enabled: true, TTL: time.Minute, Size: size}, metrics)
+		now := day
+		cached.now = func() time.Time { return now }
+
+		for _, userID := range []int{1, 2} {
+			event := &storage.Event{UserID: userID, Title: "Standup", Duration: "0:15:00", Date: day.Add(time.Hour)}
+			require.NoError(t, cached.AddEvent(ctx, event))
+		}
+		return cached, metrics, &now
+	}
+
+	list := func(t *testing.T, s *Storage, userID int) []storage.Event {
+		t.Helper()
+
+		events, err := s.ListEvents(ctx, userID, day, day.Add(24*time.Hour))
+		require.NoError(t, err)
+		return events
+	}
+
+	t.Run("Hit", func(t *testing.T) {
+		s, metrics, _ := newStorage(t, 10)
+
+		first := list(t, s, 1)
+		first[0].Title = "Mutated"
+		second := list(t, s, 1)
+
+		require.Equal(t, "Standup", second[0].Title)
+		require.Equal(t, 1, metrics.hits)
+		require.Equal(t, 1, metrics.misses)
+	})
+
+	t.Run("Write Invalidates Only That User", func(t *testing.T) {
+		s, metrics, _ := newStorage(t, 10)
+		list(t, s, 1)
+		list(t, s, 2)
+
+		event := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: day.Add(2 * time.Hour)}
+		require.NoError(t, s.AddEvent(ctx, event))
+
+		require.Len(t, list(t, s, 1), 2)
+		require.Len(t, list(t, s, 2), 1)
+		require.Equal(t, 1, metrics.hits)
+		require.Equal(t, 3, metrics.misses)
+
+		_, err := s.ApplyBatch(ctx, []storage.BatchItem{
+			{Op: storage.BatchDelete, Event: storage.Event{ID: event.ID, UserID: 1}},
+		}, true)
+		require.NoError(t, err)
+		require.Len(t, list(t, s, 1), 1)
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		s, metrics, now := newStorage(t, 10)
+		list(t, s, 1)
+
+		*now = now.Add(time.Minute)
+		list(t, s, 1)
+		require.Equal(t, 0, metrics.hits)
+		require.Equal(t, 2, metrics.misses)
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		s, metrics, _ := newStorage(t, 1)
+		list(t, s, 1)
+		list(t, s, 2)
+		list(t, s, 1)
+
+		require.Equal(t, 0, metrics.hits)
+		require.Equal(t, 1, s.lru.Len())
+	})
+
+	t.Run("User State Dropped With Last Entry", func(t *testing.T) {
+		s, _, _ := newStorage(t, 1)
+		list(t, s, 1)
+		require.Len(t, s.users, 1)
+
+		event := list(t, s, 2)[0]
+		require.Len(t, s.users, 1, "evicting the last entry of user 1 drops it")
+
+		require.NoError(t, s.DeleteEvent(ctx, event.ID, 2))
+		require.Empty(t, s.users)
+	})
+
+	t.Run("Stale Load Not Stored", func(t *testing.T) {
+		s, _, _ := newStorage(t, 10)
+
+		k := s.key(1)
+		s.invalidate(1)
+		s.put(k, nil)
+		require.Equal(t, 0, s.lru.Len())
+	})
+}
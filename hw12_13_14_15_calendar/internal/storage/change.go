@@ -0,0 +1,22 @@
+package storage
+
+import "time"
+
+type ChangeType string
+
+const (
+	ChangeCreated  ChangeType = "created"
+	ChangeUpdated  ChangeType = "updated"
+	ChangeDeleted  ChangeType = "deleted"
+	ChangeRestored ChangeType = "restored"
+)
+
+type Change struct {
+	ID          int64
+	Type        ChangeType
+	EventID     int
+	UserID      int
+	Event       Event
+	TraceParent string
+	CreatedAt   time.Time
+}
@@ -5,9 +5,24 @@ import (
 )
 
 type Event struct {
-	ID       int
-	Title    string
-	Date     time.Time
-	Duration string
+	ID          int
+	Title       string
+	Description string
+	Date        time.Time
+	Duration    string
+	UserID      int
+	DeletedAt   *time.Time
+}
+
+type Cursor struct {
+	Date time.Time
+	ID   int
+}
+
+type ListQuery struct {
 	UserID   int
+	DateFrom time.Time
+	DateTo   time.Time
+	After    *Cursor
+	Limit    int
 }
@@ -0,0 +1,56 @@
+package storage
+
+import "time"
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type Revision struct {
+	ID           int64
+	EventID      int
+	UserID       int
+	Revision     int
+	Action       ChangeType
+	Actor        string
+	Diff         []FieldChange
+	Snapshot     Event
+	RevertedFrom int
+	CreatedAt    time.Time
+}
+
+// DiffEvents lists the user-visible fields that differ between two versions of an event.
+func DiffEvents(before, after Event) []FieldChange {
+	var changes []FieldChange
+
+	compare := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	compare("title", before.Title, after.Title)
+	compare("description", before.Description, after.Description)
+	compare("date", formatTime(before.Date), formatTime(after.Date))
+	compare("duration", before.Duration, after.Duration)
+
+	var deletedBefore, deletedAfter string
+	if before.DeletedAt != nil {
+		deletedBefore = formatTime(*before.DeletedAt)
+	}
+	if after.DeletedAt != nil {
+		deletedAfter = formatTime(*after.DeletedAt)
+	}
+	compare("deletedAt", deletedBefore, deletedAfter)
+
+	return changes
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,313 @@
+package instrumentedstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/notify"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/webhook"
+)
+
+type Backend interface {
+	app.StorageService
+	webhook.Storage
+	notify.Storage
+	Ping(ctx context.Context) error
+}
+
+type closer interface {
+	Close(ctx context.Context) error
+}
+
+type Metrics interface {
+	ObserveStorage(backend, operation string, duration time.Duration, err error)
+}
+
+// Storage reports latency, errors and a trace span for every call to the wrapped backend.
+type Storage struct {
+	next    Backend
+	metrics Metrics
+	backend string
+}
+
+func New(next Backend, metrics Metrics, backend string) *Storage {
+	return &Storage{next: next, metrics: metrics, backend: backend}
+}
+
+func (s *Storage) Close(ctx context.Context) error {
+	if closer, ok := s.next.(closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+// track starts timing and tracing an operation; the returned func reports it with the final error.
+func (s *Storage) track(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	started := time.Now()
+	ctx, span := tracing.Start(ctx, "storage."+operation)
+	span.SetAttribute("storage.backend", s.backend)
+
+	return ctx, func(err *error) {
+		s.metrics.ObserveStorage(s.backend, operation, time.Since(started), *err)
+		span.End(err)
+	}
+}
+
+func (s *Storage) Ping(ctx context.Context) (err error) {
+	ctx, done := s.track(ctx, "Ping")
+	defer done(&err)
+	return s.next.Ping(ctx)
+}
+
+func (s *Storage) AddEvent(ctx context.Context, event *storage.Event) (err error) {
+	ctx, done := s.track(ctx, "AddEvent")
+	defer done(&err)
+	return s.next.AddEvent(ctx, event)
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, updated *storage.Event) (err error) {
+	ctx, done := s.track(ctx, "UpdateEvent")
+	defer done(&err)
+	return s.next.UpdateEvent(ctx, updated)
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id int, userID int) (err error) {
+	ctx, done := s.track(ctx, "DeleteEvent")
+	defer done(&err)
+	return s.next.DeleteEvent(ctx, id, userID)
+}
+
+func (s *Storage) ListEvents(
+	ctx context.Context, userID int, dateFrom time.Time, dateTo time.Time,
+) (_ []storage.Event, err error) {
+	ctx, done := s.track(ctx, "ListEvents")
+	defer done(&err)
+	return s.next.ListEvents(ctx, userID, dateFrom, dateTo)
+}
+
+func (s *Storage) ListEventsPage(ctx context.Context, query storage.ListQuery) (_ []storage.Event, err error) {
+	ctx, done := s.track(ctx, "ListEventsPage")
+	defer done(&err)
+	return s.next.ListEventsPage(ctx, query)
+}
+
+func (s *Storage) SearchEvents(ctx context.Context, query storage.SearchQuery) (_ []storage.SearchResult, err error) {
+	ctx, done := s.track(ctx, "SearchEvents")
+	defer done(&err)
+	return s.next.SearchEvents(ctx, query)
+}
+
+func (s *Storage) ListChanges(ctx context.Context, userID int, after int64, limit int) (_ []storage.Change, err error) {
+	ctx, done := s.track(ctx, "ListChanges")
+	defer done(&err)
+	return s.next.ListChanges(ctx, userID, after, limit)
+}
+
+func (s *Storage) AddWebhook(ctx context.Context, webhook *storage.Webhook) (err error) {
+	ctx, done := s.track(ctx, "AddWebhook")
+	defer done(&err)
+	return s.next.AddWebhook(ctx, webhook)
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context, userID int) (_ []storage.Webhook, err error) {
+	ctx, done := s.track(ctx, "ListWebhooks")
+	defer done(&err)
+	return s.next.ListWebhooks(ctx, userID)
+}
+
+func (s *Storage) AllWebhooks(ctx context.Context) (_ []storage.Webhook, err error) {
+	ctx, done := s.track(ctx, "AllWebhooks")
+	defer done(&err)
+	return s.next.AllWebhooks(ctx)
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id int, userID int) (err error) {
+	ctx, done := s.track(ctx, "DeleteWebhook")
+	defer done(&err)
+	return s.next.DeleteWebhook(ctx, id, userID)
+}
+
+func (s *Storage) ListWebhookDeliveries(
+	ctx context.Context, userID int, webhookID int, limit int,
+) (_ []storage.WebhookDelivery, err error) {
+	ctx, done := s.track(ctx, "ListWebhookDeliveries")
+	defer done(&err)
+	return s.next.ListWebhookDeliveries(ctx, userID, webhookID, limit)
+}
+
+func (s *Storage) EnqueueDeliveries(
+	ctx context.Context, webhookID int, lastChangeID int64, deliveries []storage.WebhookDelivery,
+) (err error) {
+	ctx, done := s.track(ctx, "EnqueueDeliveries")
+	defer done(&err)
+	return s.next.EnqueueDeliveries(ctx, webhookID, lastChangeID, deliveries)
+}
+
+func (s *Storage) DueDeliveries(
+	ctx context.Context, now time.Time, limit int,
+) (_ []storage.WebhookDelivery, err error) {
+	ctx, done := s.track(ctx, "DueDeliveries")
+	defer done(&err)
+	return s.next.DueDeliveries(ctx, now, limit)
+}
+
+func (s *Storage) UpdateDelivery(ctx context.Context, delivery *storage.WebhookDelivery) (err error) {
+	ctx, done := s.track(ctx, "UpdateDelivery")
+	defer done(&err)
+	return s.next.UpdateDelivery(ctx, delivery)
+}
+
+func (s *Storage) PruneDeliveries(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, done := s.track(ctx, "PruneDeliveries")
+	defer done(&err)
+	return s.next.PruneDeliveries(ctx, before)
+}
+
+func (s *Storage) PendingDeliveries(ctx context.Context) (_ int, err error) {
+	ctx, done := s.track(ctx, "PendingDeliveries")
+	defer done(&err)
+	return s.next.PendingDeliveries(ctx)
+}
+
+func (s *Storage) ListTrash(ctx context.Context, userID int) (_ []storage.Event, err error) {
+	ctx, done := s.track(ctx, "ListTrash")
+	defer done(&err)
+	return s.next.ListTrash(ctx, userID)
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id int, userID int, deletedAfter time.Time) (err error) {
+	ctx, done := s.track(ctx, "RestoreEvent")
+	defer done(&err)
+	return s.next.RestoreEvent(ctx, id, userID, deletedAfter)
+}
+
+func (s *Storage) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (_ int, err error) {
+	ctx, done := s.track(ctx, "PurgeDeleted")
+	defer done(&err)
+	return s.next.PurgeDeleted(ctx, deletedBefore)
+}
+
+func (s *Storage) ListRevisions(ctx context.Context, id int, userID int) (_ []storage.Revision, err error) {
+	ctx, done := s.track(ctx, "ListRevisions")
+	defer done(&err)
+	return s.next.ListRevisions(ctx, id, userID)
+}
+
+func (s *Storage) RevertEvent(ctx context.Context, id int, userID int, revision int) (_ *storage.Event, err error) {
+	ctx, done := s.track(ctx, "RevertEvent")
+	defer done(&err)
+	return s.next.RevertEvent(ctx, id, userID, revision)
+}
+
+func (s *Storage) SetNotificationPreferences(
+	ctx context.Context, preferences *storage.NotificationPreferences,
+) (err error) {
+	ctx, done := s.track(ctx, "SetNotificationPreferences")
+	defer done(&err)
+	return s.next.SetNotificationPreferences(ctx, preferences)
+}
+
+func (s *Storage) GetNotificationPreferences(
+	ctx context.Context, userID int,
+) (_ *storage.NotificationPreferences, err error) {
+	ctx, done := s.track(ctx, "GetNotificationPreferences")
+	defer done(&err)
+	return s.next.GetNotificationPreferences(ctx, userID)
+}
+
+func (s *Storage) AllNotificationPreferences(ctx context.Context) (_ []storage.NotificationPreferences, err error) {
+	ctx, done := s.track(ctx, "AllNotificationPreferences")
+	defer done(&err)
+	return s.next.AllNotificationPreferences(ctx)
+}
+
+func (s *Storage) DispatchedKeys(ctx context.Context, keys []string) (_ map[string]bool, err error) {
+	ctx, done := s.track(ctx, "DispatchedKeys")
+	defer done(&err)
+	return s.next.DispatchedKeys(ctx, keys)
+}
+
+func (s *Storage) EnqueueNotifications(
+	ctx context.Context, dispatches []storage.Dispatch, notifications []storage.Notification,
+) (err error) {
+	ctx, done := s.track(ctx, "EnqueueNotifications")
+	defer done(&err)
+	return s.next.EnqueueNotifications(ctx, dispatches, notifications)
+}
+
+func (s *Storage) ClaimNotifications(
+	ctx context.Context, now time.Time, leaseUntil time.Time, limit int,
+) (_ []storage.Notification, err error) {
+	ctx, done := s.track(ctx, "ClaimNotifications")
+	defer done(&err)
+	return s.next.ClaimNotifications(ctx, now, leaseUntil, limit)
+}
+
+func (s *Storage) PruneDispatches(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, done := s.track(ctx, "PruneDispatches")
+	defer done(&err)
+	return s.next.PruneDispatches(ctx, before)
+}
+
+func (s *Storage) PruneNotifications(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, done := s.track(ctx, "PruneNotifications")
+	defer done(&err)
+	return s.next.PruneNotifications(ctx, before)
+}
+
+func (s *Storage) UpdateNotification(ctx context.Context, notification *storage.Notification) (err error) {
+	ctx, done := s.track(ctx, "UpdateNotification")
+	defer done(&err)
+	return s.next.UpdateNotification(ctx, notification)
+}
+
+func (s *Storage) ListNotifications(ctx context.Context, userID int, limit int) (_ []storage.Notification, err error) {
+	ctx, done := s.track(ctx, "ListNotifications")
+	defer done(&err)
+	return s.next.ListNotifications(ctx, userID, limit)
+}
+
+func (s *Storage) PendingNotifications(ctx context.Context) (_ int, err error) {
+	ctx, done := s.track(ctx, "PendingNotifications")
+	defer done(&err)
+	return s.next.PendingNotifications(ctx)
+}
+
+func (s *Storage) SetReminders(
+	ctx context.Context, eventID int, userID int, reminders []storage.Reminder,
+) (err error) {
+	ctx, done := s.track(ctx, "SetReminders")
+	defer done(&err)
+	return s.next.SetReminders(ctx, eventID, userID, reminders)
+}
+
+func (s *Storage) ListReminders(ctx context.Context, eventID int, userID int) (_ []storage.Reminder, err error) {
+	ctx, done := s.track(ctx, "ListReminders")
+	defer done(&err)
+	return s.next.ListReminders(ctx, eventID, userID)
+}
+
+func (s *Storage) EventReminders(ctx context.Context, eventIDs []int) (_ map[int][]storage.Reminder, err error) {
+	ctx, done := s.track(ctx, "EventReminders")
+	defer done(&err)
+	return s.next.EventReminders(ctx, eventIDs)
+}
+
+func (s *Storage) DueReminders(
+	ctx context.Context, after time.Time, until time.Time,
+) (_ []storage.DueReminder, err error) {
+	ctx, done := s.track(ctx, "DueReminders")
+	defer done(&err)
+	return s.next.DueReminders(ctx, after, until)
+}
+
+func (s *Storage) ApplyBatch(
+	ctx context.Context, items []storage.BatchItem, atomic bool,
+) (_ []storage.BatchResult, err error) {
+	ctx, done := s.track(ctx, "ApplyBatch")
+	defer done(&err)
+	return s.next.ApplyBatch(ctx, items, atomic)
+}
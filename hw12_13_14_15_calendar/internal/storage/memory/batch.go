@@ -0,0 +1,87 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) ApplyBatch(
+	ctx context.Context, items []storage.BatchItem, atomic bool,
+) ([]storage.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]storage.BatchResult, len(items))
+	if atomic && !s.checkBatch(items, results) {
+		return results, nil
+	}
+
+	for i, item := range items {
+		event := item.Event
+		results[i] = storage.BatchResult{Event: event}
+
+		var (
+			stored *storage.Event
+			err    error
+		)
+		switch item.Op {
+		case storage.BatchCreate:
+			err = s.addEvent(ctx, &event)
+			stored = &event
+		case storage.BatchUpdate:
+			stored, err = s.updateEvent(ctx, &event)
+		case storage.BatchDelete:
+			stored, err = s.deleteEvent(ctx, event.ID, event.UserID)
+		default:
+			err = app.ErrBatchOperation
+		}
+
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Event = *stored
+	}
+
+	return results, nil
+}
+
+// checkBatch dry-runs an all-or-nothing batch so that nothing is applied when
+// any item would fail; the failures and ErrBatchAborted land in results.
+func (s *Storage) checkBatch(items []storage.BatchItem, results []storage.BatchResult) bool {
+	deleted := make(map[int]bool)
+	failed := false
+
+	for i, item := range items {
+		event := item.Event
+		results[i] = storage.BatchResult{Event: event}
+
+		switch item.Op {
+		case storage.BatchCreate:
+			results[i].Err = validateNewEvent(&event)
+		case storage.BatchUpdate, storage.BatchDelete:
+			found, ok := s.events[event.UserID][event.ID]
+			if !ok || found.DeletedAt != nil || deleted[event.ID] {
+				results[i].Err = ErrEventNotFound
+			} else if item.Op == storage.BatchDelete {
+				deleted[event.ID] = true
+			}
+		default:
+			results[i].Err = app.ErrBatchOperation
+		}
+
+		failed = failed || results[i].Err != nil
+	}
+
+	if !failed {
+		return true
+	}
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = app.ErrBatchAborted
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) ListRevisions(_ context.Context, id int, userID int) ([]storage.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.Revision
+	for _, revision := range s.history[id] {
+		if revision.UserID == userID {
+			results = append(results, revision)
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Storage) RevertEvent(ctx context.Context, id int, userID int, revision int) (*storage.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions := s.history[id]
+	if revision <= 0 || revision > len(revisions) || revisions[revision-1].UserID != userID {
+		return nil, app.ErrRevisionNotFound
+	}
+
+	event, ok := s.events[userID][id]
+	if !ok || event.DeletedAt != nil {
+		return nil, ErrEventNotFound
+	}
+
+	snapshot := revisions[revision-1].Snapshot
+	before := *event
+	s.index.remove(event)
+
+	event.Title = snapshot.Title
+	event.Description = snapshot.Description
+	event.Date = snapshot.Date
+	event.Duration = snapshot.Duration
+	if !event.Date.Equal(before.Date) {
+		s.rescheduleReminders(event)
+	}
+
+	s.index.add(event)
+	s.recordChange(ctx, storage.ChangeUpdated, before, event, revision)
+
+	reverted := *event
+	return &reverted, nil
+}
@@ -0,0 +1,241 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type notificationStore struct {
+	preferences map[int]*storage.NotificationPreferences
+	// notifications are ordered by ID; pruning leaves gaps in the IDs.
+	notifications []*storage.Notification
+	keys          map[string]bool
+	dispatches    map[string]storage.Dispatch
+	lastID        int
+}
+
+func newNotificationStore() notificationStore {
+	return notificationStore{
+		preferences: make(map[int]*storage.NotificationPreferences),
+		keys:        make(map[string]bool),
+		dispatches:  make(map[string]storage.Dispatch),
+	}
+}
+
+func (s *Storage) SetNotificationPreferences(_ context.Context, preferences *storage.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	preferences.UpdatedAt = time.Now()
+
+	stored := *preferences
+	stored.Channels = append([]storage.Channel(nil), preferences.Channels...)
+	s.notify.preferences[preferences.UserID] = &stored
+
+	return nil
+}
+
+func (s *Storage) GetNotificationPreferences(
+	_ context.Context, userID int,
+) (*storage.NotificationPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preferences, ok := s.notify.preferences[userID]
+	if !ok {
+		return nil, app.ErrPreferencesNotFound
+	}
+
+	result := *preferences
+	return &result, nil
+}
+
+func (s *Storage) AllNotificationPreferences(_ context.Context) ([]storage.NotificationPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]storage.NotificationPreferences, 0, len(s.notify.preferences))
+	for _, preferences := range s.notify.preferences {
+		results = append(results, *preferences)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].UserID < results[j].UserID
+	})
+	return results, nil
+}
+
+func (s *Storage) DispatchedKeys(_ context.Context, keys []string) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dispatched := make(map[string]bool)
+	for _, key := range keys {
+		if _, ok := s.notify.dispatches[key]; ok {
+			dispatched[key] = true
+		}
+	}
+	return dispatched, nil
+}
+
+func (s *Storage) EnqueueNotifications(
+	_ context.Context, dispatches []storage.Dispatch, notifications []storage.Notification,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	recorded := make(map[string]bool, len(dispatches))
+	for _, dispatch := range dispatches {
+		if _, ok := s.notify.dispatches[dispatch.Key]; ok {
+			continue
+		}
+		dispatch.CreatedAt = now
+		s.notify.dispatches[dispatch.Key] = dispatch
+		recorded[dispatch.Key] = true
+	}
+
+	for _, notification := range notifications {
+		key := notificationKey(notification)
+		if !recorded[notification.Key] || s.notify.keys[key] {
+			continue
+		}
+		s.notify.keys[key] = true
+
+		s.notify.lastID++
+		stored := notification
+		stored.ID = s.notify.lastID
+		stored.CreatedAt = now
+		stored.UpdatedAt = now
+		s.notify.notifications = append(s.notify.notifications, &stored)
+	}
+
+	return nil
+}
+
+func notificationKey(notification storage.Notification) string {
+	return string(notification.Channel) + ":" + notification.Key
+}
+
+func (s *Storage) ClaimNotifications(
+	_ context.Context, now time.Time, leaseUntil time.Time, limit int,
+) ([]storage.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*storage.Notification
+	for _, notification := range s.notify.notifications {
+		if notification.Status == storage.DeliveryPending && !notification.NextAttemptAt.After(now) {
+			due = append(due, notification)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	if limit > 0 && limit < len(due) {
+		due = due[:limit]
+	}
+
+	results := make([]storage.Notification, 0, len(due))
+	for _, notification := range due {
+		notification.NextAttemptAt = leaseUntil
+		results = append(results, *notification)
+	}
+	return results, nil
+}
+
+func (s *Storage) PruneDispatches(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned int
+	for key, dispatch := range s.notify.dispatches {
+		if dispatch.EventDate.Before(before) {
+			delete(s.notify.dispatches, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// PruneNotifications drops finished notifications last updated before the
+// given time along with their idempotency keys.
+func (s *Storage) PruneNotifications(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.notify.notifications[:0]
+	for _, notification := range s.notify.notifications {
+		if notification.Status == storage.DeliveryPending || !notification.UpdatedAt.Before(before) {
+			kept = append(kept, notification)
+			continue
+		}
+		delete(s.notify.keys, notificationKey(*notification))
+	}
+
+	pruned := len(s.notify.notifications) - len(kept)
+	clear(s.notify.notifications[len(kept):])
+	s.notify.notifications = kept
+
+	return pruned, nil
+}
+
+func (s *Storage) UpdateNotification(_ context.Context, notification *storage.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := sort.Search(len(s.notify.notifications), func(i int) bool {
+		return s.notify.notifications[i].ID >= notification.ID
+	})
+	if i == len(s.notify.notifications) || s.notify.notifications[i].ID != notification.ID {
+		return app.ErrNotificationNotFound
+	}
+
+	stored := s.notify.notifications[i]
+	stored.Status = notification.Status
+	stored.Attempts = notification.Attempts
+	stored.Error = notification.Error
+	stored.NextAttemptAt = notification.NextAttemptAt
+	stored.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (s *Storage) ListNotifications(_ context.Context, userID int, limit int) ([]storage.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.Notification
+	for i := len(s.notify.notifications) - 1; i >= 0; i-- {
+		notification := s.notify.notifications[i]
+		if notification.UserID != userID {
+			continue
+		}
+
+		results = append(results, *notification)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Storage) PendingNotifications(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pending int
+	for _, notification := range s.notify.notifications {
+		if notification.Status == storage.DeliveryPending {
+			pending++
+		}
+	}
+	return pending, nil
+}
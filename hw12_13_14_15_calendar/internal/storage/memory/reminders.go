@@ -0,0 +1,97 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) SetReminders(_ context.Context, eventID int, userID int, reminders []storage.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.events[userID][eventID]
+	if !ok || event.DeletedAt != nil {
+		return app.ErrEventNotFound
+	}
+
+	now := time.Now()
+	stored := make([]storage.Reminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		reminder.EventID = eventID
+		reminder.UserID = userID
+		reminder.FireAt = storage.FireAt(event.Date, reminder.Offset, now)
+		stored = append(stored, reminder)
+	}
+	s.reminders[eventID] = stored
+
+	return nil
+}
+
+func (s *Storage) ListReminders(_ context.Context, eventID int, userID int) ([]storage.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	event, ok := s.events[userID][eventID]
+	if !ok || event.DeletedAt != nil {
+		return nil, app.ErrEventNotFound
+	}
+
+	return append([]storage.Reminder(nil), s.reminders[eventID]...), nil
+}
+
+func (s *Storage) EventReminders(_ context.Context, eventIDs []int) (map[int][]storage.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make(map[int][]storage.Reminder)
+	for _, id := range eventIDs {
+		if reminders := s.reminders[id]; len(reminders) > 0 {
+			results[id] = append([]storage.Reminder(nil), reminders...)
+		}
+	}
+	return results, nil
+}
+
+// DueReminders returns the reminders due in (after, until] of events that
+// have not started by until, in the order they fire.
+func (s *Storage) DueReminders(_ context.Context, after time.Time, until time.Time) ([]storage.DueReminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []storage.DueReminder
+	for eventID, reminders := range s.reminders {
+		for _, reminder := range reminders {
+			if !reminder.FireAt.After(after) || reminder.FireAt.After(until) {
+				continue
+			}
+
+			event, ok := s.events[reminder.UserID][eventID]
+			if !ok || event.DeletedAt != nil || !event.Date.After(until) {
+				continue
+			}
+			due = append(due, storage.DueReminder{Event: *event, Reminder: reminder})
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].Reminder.FireAt.Equal(due[j].Reminder.FireAt) {
+			return due[i].Reminder.FireAt.Before(due[j].Reminder.FireAt)
+		}
+		return due[i].Event.ID < due[j].Event.ID
+	})
+	return due, nil
+}
+
+// rescheduleReminders moves the reminders of an event whose start changed or
+// that came back from the trash.
+func (s *Storage) rescheduleReminders(event *storage.Event) {
+	now := time.Now()
+	for i := range s.reminders[event.ID] {
+		reminder := &s.reminders[event.ID][i]
+		reminder.FireAt = storage.FireAt(event.Date, reminder.Offset, now)
+	}
+}
@@ -0,0 +1,106 @@
+package memorystorage
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Weights mirror the defaults of ts_rank for the A (title) and B (description) labels.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+// searchIndex maps user id -> term -> event id -> accumulated term weight.
+type searchIndex map[int]map[string]map[int]float64
+
+func (idx searchIndex) add(event *storage.Event) {
+	terms := idx[event.UserID]
+	if terms == nil {
+		terms = make(map[string]map[int]float64)
+		idx[event.UserID] = terms
+	}
+
+	for term, weight := range termWeights(event) {
+		if terms[term] == nil {
+			terms[term] = make(map[int]float64)
+		}
+		terms[term][event.ID] = weight
+	}
+}
+
+func (idx searchIndex) remove(event *storage.Event) {
+	terms := idx[event.UserID]
+	for term := range termWeights(event) {
+		delete(terms[term], event.ID)
+		if len(terms[term]) == 0 {
+			delete(terms, term)
+		}
+	}
+}
+
+func termWeights(event *storage.Event) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, term := range tokenize(event.Title) {
+		weights[term] += titleWeight
+	}
+	for _, term := range tokenize(event.Description) {
+		weights[term] += descriptionWeight
+	}
+	return weights
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (s *Storage) searchScores(userID int, text string) map[int]float64 {
+	userIndex := s.index[userID]
+	total := float64(len(s.events[userID]))
+
+	var scores map[int]float64
+	seen := make(map[string]bool)
+
+	for _, term := range tokenize(text) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := userIndex[term]
+		if len(postings) == 0 {
+			return nil
+		}
+
+		idf := math.Log(1 + total/float64(len(postings)))
+		next := make(map[int]float64, len(postings))
+		for id, weight := range postings {
+			score, ok := scores[id]
+			if scores != nil && !ok {
+				continue
+			}
+			next[id] = score + weight*idf
+		}
+		scores = next
+	}
+
+	return scores
+}
+
+func sortSearchResults(results []storage.SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if !results[i].Event.Date.Equal(results[j].Event.Date) {
+			return results[i].Event.Date.Before(results[j].Event.Date)
+		}
+		return results[i].Event.ID < results[j].Event.ID
+	})
+}
@@ -2,28 +2,63 @@ package memorystorage
 
 import (
 	"context"
-	"errors"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
 )
 
-var ErrEventNotFound = errors.New("event for update not found")
+var ErrEventNotFound = app.ErrEventNotFound
 
 type EventsMap map[int]map[int]*storage.Event
 
 type Storage struct {
-	count  int
-	events EventsMap
-	mu     sync.RWMutex
+	count     int
+	events    EventsMap
+	index     searchIndex
+	changes   []storage.Change
+	history   map[int][]storage.Revision
+	hooks     webhookStore
+	notify    notificationStore
+	reminders map[int][]storage.Reminder
+	mu        sync.RWMutex
 }
 
-func (s *Storage) AddEvent(_ context.Context, event *storage.Event) error {
+func (s *Storage) Ping(_ context.Context) error {
+	return nil
+}
+
+func (s *Storage) AddEvent(ctx context.Context, event *storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.addEvent(ctx, event)
+}
+
+func (s *Storage) addEvent(ctx context.Context, event *storage.Event) error {
+	if err := validateNewEvent(event); err != nil {
+		return err
+	}
+
+	id := s.count
+
+	if s.events[event.UserID] == nil {
+		s.events[event.UserID] = make(map[int]*storage.Event)
+	}
+
+	event.ID = id
+	s.events[event.UserID][id] = event
+	s.index.add(event)
+	s.recordChange(ctx, storage.ChangeCreated, storage.Event{}, event, 0)
+	s.count++
+
+	return nil
+}
+
+func validateNewEvent(event *storage.Event) error {
 	if event.UserID == 0 {
 		return app.ErrUserIDRequired
 	}
@@ -40,39 +75,42 @@ func (s *Storage) AddEvent(_ context.Context, event *storage.Event) error {
 		return app.ErrDurationRequired
 	}
 
-	id := s.count
-
-	if s.events[event.UserID] == nil {
-		s.events[event.UserID] = make(map[int]*storage.Event)
-	}
-
-	s.events[event.UserID][id] = event
-	s.count++
-
 	return nil
 }
 
-func (s *Storage) UpdateEvent(_ context.Context, updated *storage.Event) error {
+func (s *Storage) UpdateEvent(ctx context.Context, updated *storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	_, err := s.updateEvent(ctx, updated)
+	return err
+}
+
+func (s *Storage) updateEvent(ctx context.Context, updated *storage.Event) (*storage.Event, error) {
 	if updated.UserID == 0 {
-		return app.ErrUserIDRequired
+		return nil, app.ErrUserIDRequired
 	}
 
 	if updated.ID == 0 {
-		return app.ErrEventIDRequired
+		return nil, app.ErrEventIDRequired
 	}
 
 	findEvent, ok := s.events[updated.UserID][updated.ID]
-	if !ok {
-		return ErrEventNotFound
+	if !ok || findEvent.DeletedAt != nil {
+		return nil, ErrEventNotFound
 	}
 
+	before := *findEvent
+	s.index.remove(findEvent)
+
 	if updated.Title != "" {
 		findEvent.Title = updated.Title
 	}
 
+	if updated.Description != "" {
+		findEvent.Description = updated.Description
+	}
+
 	if updated.Duration != "" {
 		findEvent.Duration = updated.Duration
 	}
@@ -80,47 +118,197 @@ func (s *Storage) UpdateEvent(_ context.Context, updated *storage.Event) error {
 	if !updated.Date.IsZero() {
 		findEvent.Date = updated.Date
 	}
+	if !findEvent.Date.Equal(before.Date) {
+		s.rescheduleReminders(findEvent)
+	}
 
 	s.events[updated.UserID][updated.ID] = findEvent
-	return nil
+	s.index.add(findEvent)
+	s.recordChange(ctx, storage.ChangeUpdated, before, findEvent, 0)
+	return findEvent, nil
 }
 
-func (s *Storage) DeleteEvent(_ context.Context, id int, userID int) error {
+func (s *Storage) DeleteEvent(ctx context.Context, id int, userID int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.events[userID][id]; !ok {
-		return ErrEventNotFound
+	_, err := s.deleteEvent(ctx, id, userID)
+	return err
+}
+
+func (s *Storage) deleteEvent(ctx context.Context, id int, userID int) (*storage.Event, error) {
+	event, ok := s.events[userID][id]
+	if !ok || event.DeletedAt != nil {
+		return nil, ErrEventNotFound
 	}
 
-	delete(s.events[userID], id)
+	before := *event
+	deletedAt := time.Now()
+	event.DeletedAt = &deletedAt
+	s.index.remove(event)
+	s.recordChange(ctx, storage.ChangeDeleted, before, event, 0)
 
-	return nil
+	return event, nil
 }
 
 func (s *Storage) ListEvents(
 	_ context.Context, userID int, dateFrom time.Time, dateTo time.Time,
 ) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var results []storage.Event
 
 	for id, event := range s.events[userID] {
+		if event.DeletedAt != nil {
+			continue
+		}
 		if (event.Date.After(dateFrom) || event.Date.Equal(dateFrom)) &&
 			(event.Date.Before(dateTo) || event.Date.Equal(dateTo)) {
 			results = append(results, storage.Event{
-				ID:       id,
-				Title:    event.Title,
-				Date:     event.Date,
-				Duration: event.Duration,
-				UserID:   event.UserID,
+				ID:          id,
+				Title:       event.Title,
+				Description: event.Description,
+				Date:        event.Date,
+				Duration:    event.Duration,
+				UserID:      event.UserID,
 			})
 		}
 	}
 	return results, nil
 }
 
+func (s *Storage) ListEventsPage(_ context.Context, query storage.ListQuery) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.Event
+
+	for _, event := range s.events[query.UserID] {
+		if event.DeletedAt != nil || event.Date.Before(query.DateFrom) || event.Date.After(query.DateTo) {
+			continue
+		}
+		if query.After != nil && !afterCursor(event, query.After) {
+			continue
+		}
+
+		results = append(results, *event)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].Date.Equal(results[j].Date) {
+			return results[i].Date.Before(results[j].Date)
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if query.Limit > 0 && query.Limit < len(results) {
+		results = results[:query.Limit]
+	}
+
+	return results, nil
+}
+
+func afterCursor(event *storage.Event, cursor *storage.Cursor) bool {
+	if event.Date.Equal(cursor.Date) {
+		return event.ID > cursor.ID
+	}
+	return event.Date.After(cursor.Date)
+}
+
+func (s *Storage) SearchEvents(_ context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.SearchResult
+
+	for id, rank := range s.searchScores(query.UserID, query.Text) {
+		event := s.events[query.UserID][id]
+		if !query.DateFrom.IsZero() && event.Date.Before(query.DateFrom) {
+			continue
+		}
+		if !query.DateTo.IsZero() && event.Date.After(query.DateTo) {
+			continue
+		}
+
+		results = append(results, storage.SearchResult{Event: *event, Rank: rank})
+	}
+
+	sortSearchResults(results)
+
+	if query.Offset >= len(results) {
+		return nil, nil
+	}
+	results = results[query.Offset:]
+	if query.Limit > 0 && query.Limit < len(results) {
+		results = results[:query.Limit]
+	}
+
+	return results, nil
+}
+
+func (s *Storage) ListChanges(_ context.Context, userID int, after int64, limit int) ([]storage.Change, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.Change
+
+	start := sort.Search(len(s.changes), func(i int) bool {
+		return s.changes[i].ID > after
+	})
+	for _, change := range s.changes[start:] {
+		if change.UserID != userID {
+			continue
+		}
+
+		results = append(results, change)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// recordChange appends the mutation to both the change feed and the event history.
+func (s *Storage) recordChange(
+	ctx context.Context, changeType storage.ChangeType, before storage.Event, event *storage.Event, revertedFrom int,
+) {
+	now := time.Now()
+
+	s.changes = append(s.changes, storage.Change{
+		ID:          int64(len(s.changes) + 1),
+		Type:        changeType,
+		EventID:     event.ID,
+		UserID:      event.UserID,
+		Event:       *event,
+		TraceParent: tracing.TraceParent(ctx),
+		CreatedAt:   now,
+	})
+
+	revisions := s.history[event.ID]
+	s.history[event.ID] = append(revisions, storage.Revision{
+		ID:           int64(len(s.changes)),
+		EventID:      event.ID,
+		UserID:       event.UserID,
+		Revision:     len(revisions) + 1,
+		Action:       changeType,
+		Actor:        app.ActorFromContext(ctx),
+		Diff:         storage.DiffEvents(before, *event),
+		Snapshot:     *event,
+		RevertedFrom: revertedFrom,
+		CreatedAt:    now,
+	})
+}
+
 func New() (*Storage, error) {
 	return &Storage{
-		count:  1,
-		events: make(EventsMap),
+		count:     1,
+		events:    make(EventsMap),
+		index:     make(searchIndex),
+		history:   make(map[int][]storage.Revision),
+		hooks:     newWebhookStore(),
+		notify:    newNotificationStore(),
+		reminders: make(map[int][]storage.Reminder),
 	}, nil
 }
@@ -141,4 +141,392 @@ func TestStorage(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, numberOfGoroutines, len(listEvents))
 	})
+
+	t.Run("Concurrent Listing And Writing", func(t *testing.T) {
+		var wg sync.WaitGroup
+		numberOfGoroutines := 35
+
+		for i := 1; i < numberOfGoroutines+1; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				event := &storage.Event{
+					UserID:   4,
+					Title:    fmt.Sprintf("Event %d", i),
+					Duration: "1:00:00",
+					Date:     time.Now(),
+				}
+				if err := storageService.AddEvent(ctx, event); err == nil && i%2 == 0 {
+					_ = storageService.DeleteEvent(ctx, event.ID, event.UserID)
+				}
+			}(i)
+			go func() {
+				defer wg.Done()
+				_, _ = storageService.ListEvents(ctx, 4, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			}()
+		}
+
+		wg.Wait()
+
+		listEvents, err := storageService.ListEvents(ctx, 4, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, numberOfGoroutines-numberOfGoroutines/2, len(listEvents))
+	})
+}
+
+func TestSearchEvents(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := New()
+	require.NoError(t, err)
+
+	march := time.Date(2024, time.March, 14, 10, 0, 0, 0, time.UTC)
+	events := []*storage.Event{
+		{UserID: 1, Title: "Sprint retro", Description: "Team retrospective", Duration: "1:00:00", Date: march},
+		{UserID: 1, Title: "Planning", Description: "Plan the sprint after retro", Duration: "1:00:00", Date: march},
+		{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: march.AddDate(0, 1, 0)},
+		{UserID: 2, Title: "Retro", Duration: "1:00:00", Date: march},
+	}
+	for _, event := range events {
+		require.NoError(t, storageService.AddEvent(ctx, event))
+	}
+
+	t.Run("Ranked By Title Weight", func(t *testing.T) {
+		results, err := storageService.SearchEvents(ctx, storage.SearchQuery{UserID: 1, Text: "retro"})
+		require.NoError(t, err)
+
+		require.Len(t, results, 3)
+		require.Equal(t, events[0].ID, results[0].Event.ID)
+		require.Equal(t, events[1].ID, results[2].Event.ID)
+		require.Greater(t, results[0].Rank, results[2].Rank)
+	})
+
+	t.Run("All Terms Required", func(t *testing.T) {
+		results, err := storageService.SearchEvents(ctx, storage.SearchQuery{UserID: 1, Text: "Sprint RETRO"})
+		require.NoError(t, err)
+
+		require.Len(t, results, 2)
+	})
+
+	t.Run("Date Range", func(t *testing.T) {
+		results, err := storageService.SearchEvents(ctx, storage.SearchQuery{
+			UserID:   1,
+			Text:     "retro",
+			DateFrom: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			DateTo:   time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+
+		require.Len(t, results, 2)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		results, err := storageService.SearchEvents(ctx, storage.SearchQuery{UserID: 1, Text: "retro", Limit: 2, Offset: 2})
+		require.NoError(t, err)
+
+		require.Len(t, results, 1)
+		require.Equal(t, events[1].ID, results[0].Event.ID)
+	})
+
+	t.Run("Index Follows Updates", func(t *testing.T) {
+		err := storageService.UpdateEvent(ctx, &storage.Event{ID: events[2].ID, UserID: 1, Title: "Demo"})
+		require.NoError(t, err)
+		require.NoError(t, storageService.DeleteEvent(ctx, events[0].ID, 1))
+
+		results, err := storageService.SearchEvents(ctx, storage.SearchQuery{UserID: 1, Text: "retro"})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+
+		results, err = storageService.SearchEvents(ctx, storage.SearchQuery{UserID: 1, Text: "demo"})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+	})
+}
+
+func TestListChanges(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := New()
+	require.NoError(t, err)
+
+	event := &storage.Event{UserID: 1, Title: "Meet", Duration: "1:00:00", Date: time.Now()}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+	require.NoError(t, storageService.AddEvent(ctx, &storage.Event{
+		UserID: 2, Title: "Other", Duration: "1:00:00", Date: time.Now(),
+	}))
+	require.NoError(t, storageService.UpdateEvent(ctx, &storage.Event{ID: event.ID, UserID: 1, Title: "Renamed"}))
+	require.NoError(t, storageService.DeleteEvent(ctx, event.ID, 1))
+
+	changes, err := storageService.ListChanges(ctx, 1, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	require.Equal(t, storage.ChangeCreated, changes[0].Type)
+	require.Equal(t, "Meet", changes[0].Event.Title)
+	require.Equal(t, storage.ChangeUpdated, changes[1].Type)
+	require.Equal(t, "Renamed", changes[1].Event.Title)
+	require.Equal(t, storage.ChangeDeleted, changes[2].Type)
+
+	resumed, err := storageService.ListChanges(ctx, 1, changes[0].ID, 1)
+	require.NoError(t, err)
+	require.Len(t, resumed, 1)
+	require.Equal(t, changes[1].ID, resumed[0].ID)
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := New()
+	require.NoError(t, err)
+
+	now := time.Now()
+	event := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: now}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+	require.NoError(t, storageService.DeleteEvent(ctx, event.ID, 1))
+
+	t.Run("Excluded From Listings", func(t *testing.T) {
+		listEvents, err := storageService.ListEvents(ctx, 1, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, listEvents)
+
+		results, err := storageService.SearchEvents(ctx, storage.SearchQuery{UserID: 1, Text: "retro"})
+		require.NoError(t, err)
+		require.Empty(t, results)
+
+		require.ErrorIs(t, storageService.DeleteEvent(ctx, event.ID, 1), app.ErrEventNotFound)
+		require.ErrorIs(t, storageService.UpdateEvent(ctx, &storage.Event{ID: event.ID, UserID: 1}), app.ErrEventNotFound)
+	})
+
+	t.Run("Listed In Trash", func(t *testing.T) {
+		trash, err := storageService.ListTrash(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		require.NotNil(t, trash[0].DeletedAt)
+	})
+
+	t.Run("Restore Window", func(t *testing.T) {
+		err := storageService.RestoreEvent(ctx, event.ID, 1, time.Now().Add(time.Hour))
+		require.ErrorIs(t, err, app.ErrRestoreWindowExpired)
+
+		require.NoError(t, storageService.RestoreEvent(ctx, event.ID, 1, now.Add(-time.Hour)))
+		require.ErrorIs(t, storageService.RestoreEvent(ctx, event.ID, 1, now.Add(-time.Hour)), app.ErrEventNotInTrash)
+
+		listEvents, err := storageService.ListEvents(ctx, 1, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, listEvents, 1)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		require.NoError(t, storageService.DeleteEvent(ctx, event.ID, 1))
+
+		purged, err := storageService.PurgeDeleted(ctx, now.Add(-time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, purged)
+
+		purged, err = storageService.PurgeDeleted(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 1, purged)
+
+		trash, err := storageService.ListTrash(ctx, 1)
+		require.NoError(t, err)
+		require.Empty(t, trash)
+	})
+}
+
+func TestHistory(t *testing.T) {
+	ctx := app.WithActor(context.Background(), "user:1")
+
+	storageService, err := New()
+	require.NoError(t, err)
+
+	event := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: time.Now()}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+	require.NoError(t, storageService.UpdateEvent(
+		app.WithActor(ctx, "admin"), &storage.Event{ID: event.ID, UserID: 1, Title: "Demo", Duration: "2:00:00"},
+	))
+
+	revisions, err := storageService.ListRevisions(ctx, event.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+
+	require.Equal(t, storage.ChangeCreated, revisions[0].Action)
+	require.Equal(t, "user:1", revisions[0].Actor)
+	require.Equal(t, "admin", revisions[1].Actor)
+	require.Equal(t, []storage.FieldChange{
+		{Field: "title", Old: "Retro", New: "Demo"},
+		{Field: "duration", Old: "1:00:00", New: "2:00:00"},
+	}, revisions[1].Diff)
+
+	t.Run("Revert", func(t *testing.T) {
+		reverted, err := storageService.RevertEvent(ctx, event.ID, 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, "Retro", reverted.Title)
+		require.Equal(t, "1:00:00", reverted.Duration)
+
+		revisions, err := storageService.ListRevisions(ctx, event.ID, 1)
+		require.NoError(t, err)
+		require.Len(t, revisions, 3)
+		require.Equal(t, 1, revisions[2].RevertedFrom)
+	})
+
+	t.Run("Unknown Revision", func(t *testing.T) {
+		_, err := storageService.RevertEvent(ctx, event.ID, 1, 10)
+		require.ErrorIs(t, err, app.ErrRevisionNotFound)
+
+		_, err = storageService.RevertEvent(ctx, event.ID, 2, 1)
+		require.ErrorIs(t, err, app.ErrRevisionNotFound)
+	})
+}
+
+func TestNotificationDispatches(t *testing.T) {
+	ctx := context.Background()
+	storageService, err := New()
+	require.NoError(t, err)
+
+	now := time.Now()
+	dispatch := storage.Dispatch{Key: "reminder:1:900:1700000000", EventID: 1, UserID: 1, EventDate: now.Add(time.Hour)}
+	notifications := []storage.Notification{
+		{EventID: 1, UserID: 1, Channel: storage.ChannelLog, Key: dispatch.Key, Status: storage.DeliveryPending},
+		{EventID: 1, UserID: 1, Channel: storage.ChannelEmail, Key: dispatch.Key, Status: storage.DeliveryPending},
+	}
+
+	require.NoError(t, storageService.EnqueueNotifications(ctx, []storage.Dispatch{dispatch}, notifications))
+	require.NoError(t, storageService.EnqueueNotifications(ctx, []storage.Dispatch{dispatch}, notifications))
+
+	dispatched, err := storageService.DispatchedKeys(ctx, []string{dispatch.Key, "reminder:2:900:1700000000"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{dispatch.Key: true}, dispatched)
+
+	t.Run("Claim Leases Notifications", func(t *testing.T) {
+		claimed, err := storageService.ClaimNotifications(ctx, now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2, "a repeated dispatch must not enqueue twice")
+
+		claimed, err = storageService.ClaimNotifications(ctx, now, now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Empty(t, claimed)
+
+		claimed, err = storageService.ClaimNotifications(ctx, now.Add(time.Minute), now.Add(2*time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2, "an expired lease is claimed again")
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		pruned, err := storageService.PruneDispatches(ctx, now.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 1, pruned)
+	})
+
+	t.Run("Finished Notifications Expire", func(t *testing.T) {
+		listed, err := storageService.ListNotifications(ctx, 1, 0)
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+
+		delivered := listed[1]
+		delivered.Status = storage.DeliveryDelivered
+		require.NoError(t, storageService.UpdateNotification(ctx, &delivered))
+
+		pruned, err := storageService.PruneNotifications(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 1, pruned, "a pending notification is kept")
+		require.Empty(t, storageService.notify.keys[notificationKey(delivered)])
+		require.Len(t, storageService.notify.keys, 1)
+
+		pending := listed[0]
+		pending.Attempts = 1
+		require.NoError(t, storageService.UpdateNotification(ctx, &pending), "ids stay addressable after pruning")
+		require.ErrorIs(t, storageService.UpdateNotification(ctx, &delivered), app.ErrNotificationNotFound)
+	})
+}
+
+func TestDueReminders(t *testing.T) {
+	ctx := context.Background()
+	storageService, err := New()
+	require.NoError(t, err)
+
+	now := time.Now()
+	event := &storage.Event{UserID: 1, Title: "Review", Duration: "1:00:00", Date: now.Add(2 * time.Hour)}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+	require.NoError(t, storageService.SetReminders(ctx, event.ID, 1, []storage.Reminder{
+		{Offset: 24 * time.Hour}, {Offset: time.Hour},
+	}))
+
+	offsets := func(after, until time.Time) []time.Duration {
+		due, err := storageService.DueReminders(ctx, after, until)
+		require.NoError(t, err)
+
+		result := make([]time.Duration, 0, len(due))
+		for _, item := range due {
+			require.Equal(t, event.ID, item.Event.ID)
+			result = append(result, item.Reminder.Offset)
+		}
+		return result
+	}
+
+	later := time.Now().Add(time.Second)
+	require.Equal(t, []time.Duration{24 * time.Hour}, offsets(now.Add(-time.Second), later),
+		"an overdue reminder fires at once")
+	require.Empty(t, offsets(later, now.Add(59*time.Minute)))
+	require.Equal(t, []time.Duration{time.Hour}, offsets(later, now.Add(time.Hour)))
+
+	t.Run("Moved With Event", func(t *testing.T) {
+		moved := &storage.Event{ID: event.ID, UserID: 1, Date: now.Add(3 * time.Hour)}
+		require.NoError(t, storageService.UpdateEvent(ctx, moved))
+
+		require.Empty(t, offsets(later, now.Add(time.Hour)))
+		require.Equal(t, []time.Duration{time.Hour}, offsets(later, now.Add(2*time.Hour)))
+	})
+
+	t.Run("Not For Deleted Events", func(t *testing.T) {
+		require.NoError(t, storageService.DeleteEvent(ctx, event.ID, 1))
+		require.Empty(t, offsets(later, now.Add(2*time.Hour)))
+	})
+}
+
+func TestApplyBatch(t *testing.T) {
+	ctx := context.Background()
+
+	storageService, err := New()
+	require.NoError(t, err)
+
+	now := time.Now()
+	existing := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: now}
+	require.NoError(t, storageService.AddEvent(ctx, existing))
+
+	items := []storage.BatchItem{
+		{Op: storage.BatchCreate, Event: storage.Event{UserID: 1, Title: "Demo", Duration: "0:30:00", Date: now}},
+		{Op: storage.BatchUpdate, Event: storage.Event{ID: existing.ID, UserID: 1, Title: "Sprint retro"}},
+		{Op: storage.BatchDelete, Event: storage.Event{ID: existing.ID, UserID: 1}},
+		{Op: storage.BatchDelete, Event: storage.Event{ID: existing.ID, UserID: 1}},
+	}
+
+	t.Run("Atomic", func(t *testing.T) {
+		results, err := storageService.ApplyBatch(ctx, items, true)
+		require.NoError(t, err)
+		require.ErrorIs(t, results[0].Err, app.ErrBatchAborted)
+		require.ErrorIs(t, results[3].Err, app.ErrEventNotFound, "deleted earlier in the batch")
+
+		listEvents, err := storageService.ListEvents(ctx, 1, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, listEvents, 1)
+		require.Equal(t, "Retro", listEvents[0].Title)
+	})
+
+	t.Run("Partial", func(t *testing.T) {
+		results, err := storageService.ApplyBatch(ctx, items, false)
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		require.NotZero(t, results[0].Event.ID)
+		require.NoError(t, results[1].Err)
+		require.Equal(t, "Sprint retro", results[1].Event.Title)
+		require.Equal(t, "1:00:00", results[1].Event.Duration, "unset fields are kept")
+		require.NoError(t, results[2].Err)
+		require.NotNil(t, results[2].Event.DeletedAt)
+		require.ErrorIs(t, results[3].Err, app.ErrEventNotFound)
+
+		listEvents, err := storageService.ListEvents(ctx, 1, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, listEvents, 1)
+		require.Equal(t, "Demo", listEvents[0].Title)
+	})
 }
@@ -0,0 +1,67 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) ListTrash(_ context.Context, userID int) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.Event
+	for _, event := range s.events[userID] {
+		if event.DeletedAt != nil {
+			results = append(results, *event)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].DeletedAt.After(*results[j].DeletedAt)
+	})
+	return results, nil
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id int, userID int, deletedAfter time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.events[userID][id]
+	if !ok || event.DeletedAt == nil {
+		return app.ErrEventNotInTrash
+	}
+
+	if event.DeletedAt.Before(deletedAfter) {
+		return app.ErrRestoreWindowExpired
+	}
+
+	before := *event
+	event.DeletedAt = nil
+	s.rescheduleReminders(event)
+	s.index.add(event)
+	s.recordChange(ctx, storage.ChangeRestored, before, event, 0)
+
+	return nil
+}
+
+func (s *Storage) PurgeDeleted(_ context.Context, deletedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int
+	for _, events := range s.events {
+		for id, event := range events {
+			if event.DeletedAt != nil && event.DeletedAt.Before(deletedBefore) {
+				delete(events, id)
+				delete(s.reminders, id)
+				purged++
+			}
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,221 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type webhookStore struct {
+	webhooks map[int]*storage.Webhook
+	// deliveries are ordered by ID; pruning leaves gaps in the IDs.
+	deliveries     []*storage.WebhookDelivery
+	keys           map[int]map[string]bool
+	lastID         int
+	lastDeliveryID int
+}
+
+func newWebhookStore() webhookStore {
+	return webhookStore{
+		webhooks: make(map[int]*storage.Webhook),
+		keys:     make(map[int]map[string]bool),
+	}
+}
+
+func (s *Storage) AddWebhook(_ context.Context, webhook *storage.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks.lastID++
+	webhook.ID = s.hooks.lastID
+	webhook.CreatedAt = time.Now()
+	webhook.LastChangeID = int64(len(s.changes))
+
+	stored := *webhook
+	s.hooks.webhooks[webhook.ID] = &stored
+	s.hooks.keys[webhook.ID] = make(map[string]bool)
+
+	return nil
+}
+
+func (s *Storage) ListWebhooks(_ context.Context, userID int) ([]storage.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.collectWebhooks(func(webhook *storage.Webhook) bool {
+		return webhook.UserID == userID
+	}), nil
+}
+
+func (s *Storage) AllWebhooks(_ context.Context) ([]storage.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.collectWebhooks(func(*storage.Webhook) bool { return true }), nil
+}
+
+func (s *Storage) collectWebhooks(match func(webhook *storage.Webhook) bool) []storage.Webhook {
+	var results []storage.Webhook
+	for _, webhook := range s.hooks.webhooks {
+		if match(webhook) {
+			results = append(results, *webhook)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+func (s *Storage) DeleteWebhook(_ context.Context, id int, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.hooks.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return app.ErrWebhookNotFound
+	}
+
+	delete(s.hooks.webhooks, id)
+	delete(s.hooks.keys, id)
+
+	return nil
+}
+
+func (s *Storage) EnqueueDeliveries(
+	_ context.Context, webhookID int, lastChangeID int64, deliveries []storage.WebhookDelivery,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.hooks.webhooks[webhookID]
+	if !ok {
+		return app.ErrWebhookNotFound
+	}
+
+	now := time.Now()
+	for _, delivery := range deliveries {
+		if s.hooks.keys[webhookID][delivery.Key] {
+			continue
+		}
+		s.hooks.keys[webhookID][delivery.Key] = true
+
+		s.hooks.lastDeliveryID++
+		stored := delivery
+		stored.ID = s.hooks.lastDeliveryID
+		stored.WebhookID = webhookID
+		stored.CreatedAt = now
+		stored.UpdatedAt = now
+		s.hooks.deliveries = append(s.hooks.deliveries, &stored)
+	}
+
+	if lastChangeID > webhook.LastChangeID {
+		webhook.LastChangeID = lastChangeID
+	}
+
+	return nil
+}
+
+func (s *Storage) DueDeliveries(_ context.Context, now time.Time, limit int) ([]storage.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.WebhookDelivery
+	for _, delivery := range s.hooks.deliveries {
+		if delivery.Status == storage.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			results = append(results, *delivery)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].NextAttemptAt.Before(results[j].NextAttemptAt)
+	})
+
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (s *Storage) UpdateDelivery(_ context.Context, delivery *storage.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := sort.Search(len(s.hooks.deliveries), func(i int) bool {
+		return s.hooks.deliveries[i].ID >= delivery.ID
+	})
+	if i == len(s.hooks.deliveries) || s.hooks.deliveries[i].ID != delivery.ID {
+		return app.ErrDeliveryNotFound
+	}
+
+	stored := s.hooks.deliveries[i]
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.ResponseCode = delivery.ResponseCode
+	stored.Error = delivery.Error
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (s *Storage) ListWebhookDeliveries(
+	_ context.Context, userID int, webhookID int, limit int,
+) ([]storage.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []storage.WebhookDelivery
+	for i := len(s.hooks.deliveries) - 1; i >= 0; i-- {
+		delivery := s.hooks.deliveries[i]
+		if delivery.UserID != userID || (webhookID != 0 && delivery.WebhookID != webhookID) {
+			continue
+		}
+
+		results = append(results, *delivery)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Storage) PendingDeliveries(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pending int
+	for _, delivery := range s.hooks.deliveries {
+		if delivery.Status == storage.DeliveryPending {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// PruneDeliveries drops finished deliveries last updated before the given time
+// along with their idempotency keys.
+func (s *Storage) PruneDeliveries(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.hooks.deliveries[:0]
+	for _, delivery := range s.hooks.deliveries {
+		if delivery.Status == storage.DeliveryPending || !delivery.UpdatedAt.Before(before) {
+			kept = append(kept, delivery)
+			continue
+		}
+		delete(s.hooks.keys[delivery.WebhookID], delivery.Key)
+	}
+
+	pruned := len(s.hooks.deliveries) - len(kept)
+	clear(s.hooks.deliveries[len(kept):])
+	s.hooks.deliveries = kept
+
+	return pruned, nil
+}
@@ -2,9 +2,95 @@ package storage
 
 import "time"
 
+type Channel string
+
+// NotificationSkipped marks reminders dropped because their channel is disabled
+// or the event starts before quiet hours end.
+const NotificationSkipped DeliveryStatus = "skipped"
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+	ChannelLog     Channel = "log"
+)
+
+// NotificationPreferences tell the sender where to remind a user of upcoming
+// events. QuietStart and QuietEnd are "15:04" clock times in Timezone; during
+// quiet hours deliveries wait until the quiet period ends.
+type NotificationPreferences struct {
+	UserID     int
+	Channels   []Channel
+	Email      string
+	WebhookURL string
+	QuietStart string
+	QuietEnd   string
+	Timezone   string
+	UpdatedAt  time.Time
+}
+
+func (p NotificationPreferences) Enabled(channel Channel) bool {
+	for _, enabled := range p.Channels {
+		if enabled == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Reminder asks for a notification Offset before the event starts, over
+// Channel or, when Channel is empty, over every channel the user enabled.
+// FireAt is kept by the storage, see FireAt.
+type Reminder struct {
+	EventID int
+	UserID  int
+	Offset  time.Duration
+	Channel Channel
+	FireAt  time.Time
+}
+
+// DueReminder is a reminder whose time came, with the event it is for.
+type DueReminder struct {
+	Event    Event
+	Reminder Reminder
+}
+
+// FireAt is when a reminder offset before start is due. A reminder set, or
+// moved with its event, after that time has passed is due at once.
+func FireAt(start time.Time, offset time.Duration, now time.Time) time.Time {
+	fireAt := start.Add(-offset)
+	if fireAt.Before(now) {
+		return now
+	}
+	return fireAt
+}
+
+// Dispatch records that the reminder of an event at one offset before its
+// start was scheduled. Key identifies the (event, offset, start) triple and is
+// the idempotency key of every notification the dispatch produced.
+type Dispatch struct {
+	Key       string
+	EventID   int
+	UserID    int
+	Offset    time.Duration
+	EventDate time.Time
+	CreatedAt time.Time
+}
+
+// Notification is the reminder of one event sent over one channel.
 type Notification struct {
-	EventID string
-	Title   string
-	Date    time.Time
-	UserID  string
+	ID            int
+	EventID       int
+	UserID        int
+	Title         string
+	Date          time.Time
+	Channel       Channel
+	Key           string
+	Subject       string
+	Body          string
+	Status        DeliveryStatus
+	Attempts      int
+	Error         string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
@@ -0,0 +1,17 @@
+package storage
+
+import "time"
+
+type SearchQuery struct {
+	UserID   int
+	Text     string
+	DateFrom time.Time
+	DateTo   time.Time
+	Limit    int
+	Offset   int
+}
+
+type SearchResult struct {
+	Event Event
+	Rank  float64
+}
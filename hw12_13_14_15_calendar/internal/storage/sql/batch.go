@@ -0,0 +1,190 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+var errBatchRollback = errors.New("batch rolled back")
+
+// ApplyBatch runs every item in one transaction: updates and deletes one by
+// one in request order, creates afterwards through COPY. In partial mode each
+// step runs in a savepoint so a failing item only rolls back itself; in atomic
+// mode the first failing item rolls back the batch.
+func (s *Storage) ApplyBatch(
+	ctx context.Context, items []storage.BatchItem, atomic bool,
+) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(items))
+
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		var creates []int
+		for i, item := range items {
+			results[i].Event = item.Event
+			if item.Op == storage.BatchCreate {
+				creates = append(creates, i)
+				continue
+			}
+
+			var stored *storage.Event
+			err := step(ctx, tx, atomic, func(tx pgx.Tx) (err error) {
+				switch item.Op {
+				case storage.BatchUpdate:
+					stored, err = updateEvent(ctx, tx, &item.Event)
+				case storage.BatchDelete:
+					stored, err = deleteEvent(ctx, tx, item.Event.ID, item.Event.UserID)
+				default:
+					err = app.ErrBatchOperation
+				}
+				return err
+			})
+			if err != nil {
+				results[i].Err = err
+				if atomic {
+					return errBatchRollback
+				}
+				continue
+			}
+			results[i].Event = *stored
+		}
+
+		return createEvents(ctx, tx, atomic, creates, results)
+	})
+
+	if errors.Is(err, errBatchRollback) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = app.ErrBatchAborted
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// createEvents inserts the created events with a single COPY. When that fails
+// the rows are retried one by one to find the failing ones, so each error is
+// reported on its own item; in atomic mode they then roll the batch back.
+func createEvents(ctx context.Context, tx pgx.Tx, atomic bool, creates []int, results []storage.BatchResult) error {
+	if len(creates) == 0 {
+		return nil
+	}
+
+	events := make([]*storage.Event, 0, len(creates))
+	for _, i := range creates {
+		events = append(events, &results[i].Event)
+	}
+
+	// always in a savepoint: the transaction has to survive for the retries
+	err := step(ctx, tx, false, func(tx pgx.Tx) error {
+		return copyEvents(ctx, tx, events)
+	})
+	if err == nil {
+		return nil
+	}
+
+	failed := false
+	for _, i := range creates {
+		event := &results[i].Event
+		event.ID = 0
+		results[i].Err = step(ctx, tx, false, func(tx pgx.Tx) error {
+			return insertEvent(ctx, tx, event)
+		})
+		if results[i].Err != nil {
+			failed = true
+			event.ID = 0
+		}
+	}
+
+	if atomic && failed {
+		for _, event := range events {
+			event.ID = 0
+		}
+		return errBatchRollback
+	}
+	return nil
+}
+
+// copyEvents reserves the ids up front, as COPY returns none, and copies into
+// a staging table so that Postgres parses the durations as for a plain INSERT.
+func copyEvents(ctx context.Context, tx pgx.Tx, events []*storage.Event) error {
+	rows, err := tx.Query(
+		ctx,
+		"SELECT nextval(pg_get_serial_sequence('events', 'id')) FROM generate_series(1, $1)",
+		len(events),
+	)
+	if err != nil {
+		return err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"CREATE TEMP TABLE batch_events "+
+			"(id integer, title text, description text, date timestamp, duration text, user_id integer) ON COMMIT DROP",
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"batch_events"},
+		[]string{"id", "title", "description", "date", "duration", "user_id"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			event := events[i]
+			return []any{ids[i], event.Title, event.Description, event.Date, event.Duration, event.UserID}, nil
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO events (id, title, description, date, duration, user_id) "+
+			"SELECT id, title, description, date, duration::interval, user_id FROM batch_events",
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, event := range events {
+		event.ID = ids[i]
+		if err := recordChange(ctx, tx, storage.ChangeCreated, storage.Event{}, event, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step runs fn in a savepoint in partial mode and straight in tx otherwise,
+// where any failure dooms the whole transaction anyway.
+func step(ctx context.Context, tx pgx.Tx, atomic bool, fn func(tx pgx.Tx) error) error {
+	if atomic {
+		return fn(tx)
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = savepoint.Rollback(ctx)
+	}()
+
+	if err := fn(savepoint); err != nil {
+		return err
+	}
+	return savepoint.Commit(ctx)
+}
@@ -0,0 +1,79 @@
+package sqlstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBatch(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	count := func(t *testing.T, s *Storage, table string, userID int) int {
+		t.Helper()
+
+		var n int
+		require.NoError(t, s.db.QueryRow(ctx, "SELECT count(*) FROM "+table+" WHERE user_id = $1", userID).Scan(&n))
+		return n
+	}
+	create := func(userID int, title, duration string) storage.BatchItem {
+		return storage.BatchItem{
+			Op:    storage.BatchCreate,
+			Event: storage.Event{UserID: userID, Title: title, Duration: duration, Date: day},
+		}
+	}
+
+	t.Run("Partial Create Failure Fails Only Its Item", func(t *testing.T) {
+		s, userID := newTestStorage(t)
+		existing := &storage.Event{UserID: userID, Title: "Retro", Duration: "1:00:00", Date: day}
+		require.NoError(t, s.AddEvent(ctx, existing))
+
+		results, err := s.ApplyBatch(ctx, []storage.BatchItem{
+			create(userID, "Planning", "1:00:00"),
+			create(userID, "Broken", "not a duration"),
+			{Op: storage.BatchUpdate, Event: storage.Event{ID: existing.ID, UserID: userID, Title: "Sprint retro"}},
+			create(userID, "Demo", "0:30:00"),
+		}, false)
+		require.NoError(t, err)
+
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, results[1].Err, &pgErr)
+		require.Zero(t, results[1].Event.ID)
+		for _, i := range []int{0, 2, 3} {
+			require.NoError(t, results[i].Err)
+			require.NotZero(t, results[i].Event.ID)
+		}
+
+		events, err := s.ListEvents(ctx, userID, day, day)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		require.Equal(t, 4, count(t, s, "event_changes", userID), "the add and one change per applied operation")
+	})
+
+	t.Run("Atomic Failure Leaves Nothing Behind", func(t *testing.T) {
+		s, userID := newTestStorage(t)
+
+		results, err := s.ApplyBatch(ctx, []storage.BatchItem{
+			create(userID, "Planning", "1:00:00"),
+			create(userID, "Broken", "not a duration"),
+			create(userID, "Demo", "0:30:00"),
+		}, true)
+		require.NoError(t, err)
+
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, results[1].Err, &pgErr, "the failing row reports its own error")
+		for _, i := range []int{0, 2} {
+			require.ErrorIs(t, results[i].Err, app.ErrBatchAborted)
+			require.Zero(t, results[i].Event.ID)
+		}
+
+		require.Zero(t, count(t, s, "events", userID))
+		require.Zero(t, count(t, s, "event_changes", userID))
+	})
+}
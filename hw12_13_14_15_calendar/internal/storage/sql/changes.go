@@ -0,0 +1,91 @@
+package sqlstorage
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// changesLockSpace is the first key of the two-key advisory locks that order a
+// user's changes; two-key locks never collide with the single-key leader lock.
+const changesLockSpace = 1
+
+// recordChange writes the mutation to the change outbox and the event history
+// within the caller's transaction.
+//
+// Readers page through a user's changes by id, so a change must never become
+// visible after one with a higher id, or a reader that has moved past it would
+// skip it for good. Ids come from a sequence and transactions commit in any
+// order, so the insert takes a per-user lock held until commit: the user's
+// next change gets its id only once the previous one committed or rolled back.
+func recordChange(
+	ctx context.Context, tx pgx.Tx, changeType storage.ChangeType, before storage.Event, event *storage.Event,
+	revertedFrom int,
+) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if err := lockChanges(ctx, tx, event.UserID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO event_changes (type, event_id, user_id, payload, trace_parent) VALUES ($1, $2, $3, $4, $5)",
+		changeType, event.ID, event.UserID, payload, tracing.TraceParent(ctx),
+	)
+	if err != nil {
+		return err
+	}
+
+	return insertRevision(ctx, tx, changeType, before, event, revertedFrom)
+}
+
+// lockChanges takes the lock ordering the user's changes. Writers that lock an
+// event row take it first, so the two locks are always taken in the same order.
+func lockChanges(ctx context.Context, tx pgx.Tx, userID int) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, $2)", changesLockSpace, userID)
+	return err
+}
+
+func (s *Storage) ListChanges(ctx context.Context, userID int, after int64, limit int) ([]storage.Change, error) {
+	var changes []storage.Change
+
+	rows, err := s.db.Query(
+		ctx,
+		"SELECT id, type, event_id, user_id, payload, trace_parent, created_at FROM event_changes "+
+			"WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3",
+		userID, after, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			change  storage.Change
+			payload []byte
+		)
+
+		err = rows.Scan(
+			&change.ID, &change.Type, &change.EventID, &change.UserID, &payload, &change.TraceParent, &change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(payload, &change.Event); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
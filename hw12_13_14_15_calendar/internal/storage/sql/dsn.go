@@ -0,0 +1,71 @@
+package sqlstorage
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+)
+
+const redacted = "xxxxx"
+
+// DSN builds a postgres URL with every component escaped.
+func DSN(conf config.DBConf) string {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"sslmode":     conf.SSLMode,
+		"sslrootcert": conf.SSLRootCert,
+		"sslcert":     conf.SSLCert,
+		"sslkey":      conf.SSLKey,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(conf.Username, conf.Password),
+		Host:     net.JoinHostPort(conf.Host, conf.Port),
+		Path:     "/" + conf.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// redactedError hides credentials from the message of err while keeping it
+// available to errors.Is and errors.As.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+func redact(err error, conf config.DBConf) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	if conf.Password != "" {
+		for _, secret := range []string{
+			DSN(conf),
+			url.UserPassword(conf.Username, conf.Password).String(),
+			url.QueryEscape(conf.Password),
+			url.PathEscape(conf.Password),
+			conf.Password,
+		} {
+			message = strings.ReplaceAll(message, secret, redacted)
+		}
+	}
+
+	return &redactedError{message: message, err: err}
+}
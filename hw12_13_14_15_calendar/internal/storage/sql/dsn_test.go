@@ -0,0 +1,57 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDSN(t *testing.T) {
+	conf := config.DBConf{
+		Host:     "db.local",
+		Port:     "5433",
+		Database: "calendar",
+		Username: "app@calendar",
+		Password: "p@ss:w/rd?#%",
+		SSLMode:  "require",
+	}
+
+	parsed, err := pgx.ParseConfig(DSN(conf))
+	require.NoError(t, err)
+	require.Equal(t, "db.local", parsed.Host)
+	require.Equal(t, uint16(5433), parsed.Port)
+	require.Equal(t, "calendar", parsed.Database)
+	require.Equal(t, "app@calendar", parsed.User)
+	require.Equal(t, "p@ss:w/rd?#%", parsed.Password)
+	require.NotNil(t, parsed.TLSConfig)
+
+	conf.SSLMode, conf.SSLRootCert = "verify-full", "/etc/ssl/ca.pem"
+	require.Contains(t, DSN(conf), "sslmode=verify-full&sslrootcert=%2Fetc%2Fssl%2Fca.pem")
+}
+
+func TestRedact(t *testing.T) {
+	conf := config.DBConf{Username: "app", Password: "s3cr&t", Host: "localhost", Port: "5432", Database: "calendar"}
+	cause := errors.New("cannot connect to " + DSN(conf) + " with password s3cr&t")
+
+	err := redact(cause, conf)
+	require.NotContains(t, err.Error(), "s3cr")
+	require.Contains(t, err.Error(), redacted)
+	require.ErrorIs(t, err, cause)
+	require.NoError(t, redact(nil, conf))
+}
+
+func TestConnectErrorIsRedacted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := New(ctx, config.DBConf{
+		Host: "127.0.0.1", Port: "1", Database: "calendar", Username: "app", Password: "hunter2", SSLMode: "disable",
+	})
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "hunter2")
+}
@@ -0,0 +1,131 @@
+package sqlstorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+const revisionColumns = "id, event_id, user_id, revision, action, actor, diff, snapshot, reverted_from, created_at"
+
+func insertRevision(
+	ctx context.Context, tx pgx.Tx, action storage.ChangeType, before storage.Event, event *storage.Event,
+	revertedFrom int,
+) error {
+	diff, err := json.Marshal(storage.DiffEvents(before, *event))
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		"INSERT INTO event_history (event_id, user_id, revision, action, actor, diff, snapshot, reverted_from, created_at) "+
+			"SELECT $1, $2, coalesce(max(revision), 0) + 1, $3, $4, $5, $6, $7, $8 FROM event_history WHERE event_id = $1",
+		event.ID, event.UserID, action, app.ActorFromContext(ctx), diff, snapshot, revertedFrom, time.Now().UTC(),
+	)
+	return err
+}
+
+func (s *Storage) ListRevisions(ctx context.Context, id int, userID int) ([]storage.Revision, error) {
+	var revisions []storage.Revision
+
+	rows, err := s.db.Query(
+		ctx,
+		"SELECT "+revisionColumns+" FROM event_history WHERE event_id = $1 AND user_id = $2 ORDER BY revision",
+		id, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (s *Storage) RevertEvent(ctx context.Context, id int, userID int, revision int) (*storage.Event, error) {
+	var event storage.Event
+
+	err := s.inTx(ctx, func(tx pgx.Tx) error {
+		before, err := lockEvent(ctx, tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		target, err := scanRevision(tx.QueryRow(
+			ctx,
+			"SELECT "+revisionColumns+" FROM event_history WHERE event_id = $1 AND user_id = $2 AND revision = $3",
+			id, userID, revision,
+		))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return app.ErrRevisionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		snapshot := target.Snapshot
+		err = scanEvent(tx.QueryRow(
+			ctx,
+			"UPDATE events SET title = $1, description = $2, duration = $3, date = $4 WHERE id = $5 RETURNING "+eventColumns,
+			snapshot.Title, snapshot.Description, snapshot.Duration, snapshot.Date, id,
+		), &event)
+		if err != nil {
+			return err
+		}
+		if !event.Date.Equal(before.Date) {
+			if err := rescheduleReminders(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		return recordChange(ctx, tx, storage.ChangeUpdated, *before, &event, revision)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func scanRevision(row pgx.Row) (*storage.Revision, error) {
+	var (
+		revision       storage.Revision
+		diff, snapshot []byte
+	)
+
+	err := row.Scan(
+		&revision.ID, &revision.EventID, &revision.UserID, &revision.Revision, &revision.Action, &revision.Actor,
+		&diff, &snapshot, &revision.RevertedFrom, &revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(diff, &revision.Diff); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
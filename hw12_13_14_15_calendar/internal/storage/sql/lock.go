@@ -0,0 +1,75 @@
+package sqlstorage
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/leader"
+	"github.com/jackc/pgx/v5"
+)
+
+// AdvisoryLock is a session level Postgres advisory lock held on a connection
+// of its own: the server releases it when the holder's session ends, so a
+// crashed leader cannot keep the lock.
+type AdvisoryLock struct {
+	conf config.DBConf
+	key  int64
+	conn *pgx.Conn
+}
+
+func NewAdvisoryLock(conf config.DBConf, name string) *AdvisoryLock {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(name))
+
+	return &AdvisoryLock{conf: conf, key: int64(hash.Sum64())}
+}
+
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	if l.conn == nil {
+		conn, err := pgx.Connect(ctx, DSN(l.conf))
+		if err != nil {
+			return false, redact(err, l.conf)
+		}
+		l.conn = conn
+	}
+
+	var acquired bool
+	err := l.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired)
+	if err != nil {
+		l.disconnect(ctx)
+		return false, err
+	}
+
+	return acquired, nil
+}
+
+// Check pings the lock's session; the lock lives exactly as long as it does.
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	if l.conn == nil {
+		return leader.ErrNotHeld
+	}
+
+	if err := l.conn.Ping(ctx); err != nil {
+		l.disconnect(ctx)
+		return err
+	}
+	return nil
+}
+
+// Unlock ends the session, which releases the lock even when the unlock
+// query itself fails.
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	l.disconnect(ctx)
+	return err
+}
+
+func (l *AdvisoryLock) disconnect(ctx context.Context) {
+	_ = l.conn.Close(ctx)
+	l.conn = nil
+}
@@ -0,0 +1,54 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLock(t *testing.T) {
+	ctx := context.Background()
+	conf := testDBConf(t)
+
+	name := fmt.Sprintf("test-lock-%s", t.Name())
+	first, second := NewAdvisoryLock(conf, name), NewAdvisoryLock(conf, name)
+	t.Cleanup(func() {
+		_ = first.Unlock(ctx)
+		_ = second.Unlock(ctx)
+	})
+
+	acquired, err := first.TryLock(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.NoError(t, first.Check(ctx))
+
+	t.Run("Held Lock Refused", func(t *testing.T) {
+		acquired, err := second.TryLock(ctx)
+		require.NoError(t, err)
+		require.False(t, acquired)
+	})
+
+	t.Run("Taken Over After Unlock", func(t *testing.T) {
+		require.NoError(t, first.Unlock(ctx))
+		require.Error(t, first.Check(ctx))
+
+		acquired, err := second.TryLock(ctx)
+		require.NoError(t, err)
+		require.True(t, acquired)
+	})
+
+	t.Run("Released When The Session Ends", func(t *testing.T) {
+		// a holder that dies without unlocking only loses its session
+		require.NoError(t, second.conn.Close(ctx))
+
+		// the server ends the session, and drops the lock, a moment after the client leaves
+		require.Eventually(t, func() bool {
+			acquired, err := first.TryLock(ctx)
+			return err == nil && acquired
+		}, 5*time.Second, 50*time.Millisecond)
+		require.Error(t, second.Check(ctx))
+	})
+}
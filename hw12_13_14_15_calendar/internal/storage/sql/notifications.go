@@ -0,0 +1,258 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	preferencesColumns  = "user_id, channels, email, webhook_url, quiet_start, quiet_end, timezone, updated_at"
+	notificationColumns = "id, event_id, user_id, title, date, channel, key, subject, body, status, attempts, " +
+		"error, next_attempt_at, created_at, updated_at"
+)
+
+func (s *Storage) SetNotificationPreferences(ctx context.Context, preferences *storage.NotificationPreferences) error {
+	preferences.UpdatedAt = time.Now().UTC()
+
+	_, err := s.db.Exec(
+		ctx,
+		"INSERT INTO notification_preferences ("+preferencesColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8) "+
+			"ON CONFLICT (user_id) DO UPDATE SET channels = $2, email = $3, webhook_url = $4, "+
+			"quiet_start = $5, quiet_end = $6, timezone = $7, updated_at = $8",
+		preferences.UserID, channelsToStrings(preferences.Channels), preferences.Email, preferences.WebhookURL,
+		preferences.QuietStart, preferences.QuietEnd, preferences.Timezone, preferences.UpdatedAt,
+	)
+	return err
+}
+
+func (s *Storage) GetNotificationPreferences(
+	ctx context.Context, userID int,
+) (*storage.NotificationPreferences, error) {
+	preferences, err := s.queryPreferences(
+		ctx, "SELECT "+preferencesColumns+" FROM notification_preferences WHERE user_id = $1", userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(preferences) == 0 {
+		return nil, app.ErrPreferencesNotFound
+	}
+	return &preferences[0], nil
+}
+
+func (s *Storage) AllNotificationPreferences(ctx context.Context) ([]storage.NotificationPreferences, error) {
+	return s.queryPreferences(ctx, "SELECT "+preferencesColumns+" FROM notification_preferences ORDER BY user_id")
+}
+
+func (s *Storage) queryPreferences(
+	ctx context.Context, query string, args ...any,
+) ([]storage.NotificationPreferences, error) {
+	var results []storage.NotificationPreferences
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			preferences storage.NotificationPreferences
+			channels    []string
+		)
+
+		err = rows.Scan(
+			&preferences.UserID, &channels, &preferences.Email, &preferences.WebhookURL,
+			&preferences.QuietStart, &preferences.QuietEnd, &preferences.Timezone, &preferences.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, channel := range channels {
+			preferences.Channels = append(preferences.Channels, storage.Channel(channel))
+		}
+		results = append(results, preferences)
+	}
+
+	return results, rows.Err()
+}
+
+func (s *Storage) DispatchedKeys(ctx context.Context, keys []string) (map[string]bool, error) {
+	rows, err := s.db.Query(ctx, "SELECT key FROM notification_dispatches WHERE key = ANY($1)", keys)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	dispatched := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		dispatched[key] = true
+	}
+
+	return dispatched, rows.Err()
+}
+
+// EnqueueNotifications records the dispatches and, in the same transaction,
+// enqueues only the notifications of dispatches that were not recorded before,
+// so concurrent or repeated scans schedule every reminder once.
+func (s *Storage) EnqueueNotifications(
+	ctx context.Context, dispatches []storage.Dispatch, notifications []storage.Notification,
+) error {
+	now := time.Now().UTC()
+
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		recorded := make(map[string]bool, len(dispatches))
+		for _, dispatch := range dispatches {
+			tag, err := tx.Exec(
+				ctx,
+				"INSERT INTO notification_dispatches (key, event_id, user_id, offset_seconds, event_date, created_at) "+
+					"VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (key) DO NOTHING",
+				dispatch.Key, dispatch.EventID, dispatch.UserID, int64(dispatch.Offset.Seconds()),
+				dispatch.EventDate.UTC(), now,
+			)
+			if err != nil {
+				return err
+			}
+			recorded[dispatch.Key] = tag.RowsAffected() > 0
+		}
+
+		for _, notification := range notifications {
+			if !recorded[notification.Key] {
+				continue
+			}
+
+			_, err := tx.Exec(
+				ctx,
+				"INSERT INTO notifications "+
+					"(event_id, user_id, title, date, channel, key, subject, body, status, next_attempt_at, "+
+					"created_at, updated_at) "+
+					"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11) ON CONFLICT (channel, key) DO NOTHING",
+				notification.EventID, notification.UserID, notification.Title, notification.Date.UTC(),
+				notification.Channel, notification.Key, notification.Subject, notification.Body,
+				notification.Status, notification.NextAttemptAt.UTC(), now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimNotifications returns due notifications and pushes their next attempt
+// to leaseUntil, so another sender skips them while they are being sent.
+func (s *Storage) ClaimNotifications(
+	ctx context.Context, now time.Time, leaseUntil time.Time, limit int,
+) ([]storage.Notification, error) {
+	return s.queryNotifications(
+		ctx,
+		"UPDATE notifications SET next_attempt_at = $1 WHERE id IN ("+
+			"SELECT id FROM notifications WHERE status = $2 AND next_attempt_at <= $3 "+
+			"ORDER BY next_attempt_at, id LIMIT $4 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING "+notificationColumns,
+		leaseUntil.UTC(), storage.DeliveryPending, now.UTC(), limit,
+	)
+}
+
+func (s *Storage) PruneDispatches(ctx context.Context, before time.Time) (int, error) {
+	tag, err := s.db.Exec(ctx, "DELETE FROM notification_dispatches WHERE event_date < $1", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Storage) PruneNotifications(ctx context.Context, before time.Time) (int, error) {
+	tag, err := s.db.Exec(
+		ctx,
+		"DELETE FROM notifications WHERE status <> $1 AND updated_at < $2",
+		storage.DeliveryPending, before.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Storage) UpdateNotification(ctx context.Context, notification *storage.Notification) error {
+	notification.UpdatedAt = time.Now().UTC()
+
+	tag, err := s.db.Exec(
+		ctx,
+		"UPDATE notifications SET status = $1, attempts = $2, error = $3, next_attempt_at = $4, updated_at = $5 "+
+			"WHERE id = $6",
+		notification.Status, notification.Attempts, notification.Error,
+		notification.NextAttemptAt.UTC(), notification.UpdatedAt, notification.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return app.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *Storage) ListNotifications(ctx context.Context, userID int, limit int) ([]storage.Notification, error) {
+	return s.queryNotifications(
+		ctx,
+		"SELECT "+notificationColumns+" FROM notifications WHERE user_id = $1 ORDER BY id DESC LIMIT $2",
+		userID, limit,
+	)
+}
+
+func (s *Storage) PendingNotifications(ctx context.Context) (int, error) {
+	var pending int
+
+	err := s.db.QueryRow(
+		ctx, "SELECT count(*) FROM notifications WHERE status = $1", storage.DeliveryPending,
+	).Scan(&pending)
+	return pending, err
+}
+
+func (s *Storage) queryNotifications(ctx context.Context, query string, args ...any) ([]storage.Notification, error) {
+	var notifications []storage.Notification
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var notification storage.Notification
+
+		err = rows.Scan(
+			&notification.ID, &notification.EventID, &notification.UserID, &notification.Title, &notification.Date,
+			&notification.Channel, &notification.Key, &notification.Subject, &notification.Body,
+			&notification.Status, &notification.Attempts, &notification.Error, &notification.NextAttemptAt,
+			&notification.CreatedAt, &notification.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func channelsToStrings(channels []storage.Channel) []string {
+	result := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		result = append(result, string(channel))
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimNotifications(t *testing.T) {
+	ctx := context.Background()
+	s, userID := newTestStorage(t)
+
+	// Due long ago, so only this test's notifications are due at base.
+	base := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lease := time.Minute
+
+	const total = 10
+	dispatches := make([]storage.Dispatch, 0, total)
+	notifications := make([]storage.Notification, 0, total)
+	for i := 0; i < total; i++ {
+		key := fmt.Sprintf("test:%d:%d", userID, i)
+		dispatches = append(dispatches, storage.Dispatch{Key: key, EventID: i + 1, UserID: userID, EventDate: base})
+		notifications = append(notifications, storage.Notification{
+			EventID: i + 1, UserID: userID, Title: "Standup", Date: base, Channel: storage.ChannelLog, Key: key,
+			Status: storage.DeliveryPending, NextAttemptAt: base.Add(-time.Duration(i) * time.Second),
+		})
+	}
+	require.NoError(t, s.EnqueueNotifications(ctx, dispatches, notifications))
+
+	claim := func(now time.Time, limit int) []int {
+		claimed, err := s.ClaimNotifications(ctx, now, now.Add(lease), limit)
+		require.NoError(t, err)
+
+		var ids []int
+		for _, notification := range claimed {
+			if notification.UserID == userID {
+				ids = append(ids, notification.ID)
+			}
+		}
+		return ids
+	}
+
+	t.Run("Concurrent Claimers Do Not Overlap", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			claimed = map[int]int{}
+		)
+		for worker := 0; worker < 2; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, id := range claim(base, total/2) {
+					mu.Lock()
+					claimed[id]++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Len(t, claimed, total)
+		for id, times := range claimed {
+			require.Equal(t, 1, times, "notification %d claimed twice", id)
+		}
+	})
+
+	t.Run("Leased Notifications Not Claimed Again", func(t *testing.T) {
+		require.Empty(t, claim(base.Add(lease/2), total))
+	})
+
+	t.Run("Expired Lease Taken Over", func(t *testing.T) {
+		require.Len(t, claim(base.Add(lease), total), total)
+		require.Empty(t, claim(base.Add(lease), total))
+	})
+
+	t.Run("Finished Notifications Expire", func(t *testing.T) {
+		listed, err := s.ListNotifications(ctx, userID, 0)
+		require.NoError(t, err)
+		require.Len(t, listed, total)
+
+		delivered := listed[0]
+		delivered.Status = storage.DeliveryDelivered
+		require.NoError(t, s.UpdateNotification(ctx, &delivered))
+
+		pruned, err := s.PruneNotifications(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, pruned, 1)
+
+		listed, err = s.ListNotifications(ctx, userID, 0)
+		require.NoError(t, err)
+		require.Len(t, listed, total-1, "pending notifications are kept")
+	})
+}
@@ -0,0 +1,151 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+const reminderColumns = "event_id, user_id, offset_seconds, channel, fire_at"
+
+func (s *Storage) SetReminders(ctx context.Context, eventID int, userID int, reminders []storage.Reminder) error {
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		event, err := lockEvent(ctx, tx, eventID, userID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM event_reminders WHERE event_id = $1", eventID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, reminder := range reminders {
+			_, err := tx.Exec(
+				ctx,
+				"INSERT INTO event_reminders ("+reminderColumns+") VALUES ($1, $2, $3, $4, $5)",
+				eventID, userID, int64(reminder.Offset.Seconds()), reminder.Channel,
+				storage.FireAt(event.Date, reminder.Offset, now),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) ListReminders(ctx context.Context, eventID int, userID int) ([]storage.Reminder, error) {
+	var exists bool
+
+	err := s.db.QueryRow(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+		eventID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, app.ErrEventNotFound
+	}
+
+	reminders, err := s.queryReminders(
+		ctx, "SELECT "+reminderColumns+" FROM event_reminders WHERE event_id = $1 ORDER BY offset_seconds DESC", eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return reminders[eventID], nil
+}
+
+func (s *Storage) EventReminders(ctx context.Context, eventIDs []int) (map[int][]storage.Reminder, error) {
+	return s.queryReminders(
+		ctx,
+		"SELECT "+reminderColumns+" FROM event_reminders WHERE event_id = ANY($1) ORDER BY event_id, offset_seconds DESC",
+		eventIDs,
+	)
+}
+
+func (s *Storage) queryReminders(ctx context.Context, query string, args ...any) (map[int][]storage.Reminder, error) {
+	results := make(map[int][]storage.Reminder)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			reminder storage.Reminder
+			seconds  int64
+		)
+
+		if err := rows.Scan(
+			&reminder.EventID, &reminder.UserID, &seconds, &reminder.Channel, &reminder.FireAt,
+		); err != nil {
+			return nil, err
+		}
+
+		reminder.Offset = time.Duration(seconds) * time.Second
+		results[reminder.EventID] = append(results[reminder.EventID], reminder)
+	}
+
+	return results, rows.Err()
+}
+
+// DueReminders returns the reminders due in (after, until] of events that
+// have not started by until, in the order they fire.
+func (s *Storage) DueReminders(ctx context.Context, after time.Time, until time.Time) ([]storage.DueReminder, error) {
+	rows, err := s.db.Query(
+		ctx,
+		"SELECT e.id, e.title, coalesce(e.description, ''), e.date, e.duration::text, e.user_id, "+
+			"r.offset_seconds, r.channel, r.fire_at "+
+			"FROM event_reminders r JOIN events e ON e.id = r.event_id "+
+			"WHERE r.fire_at > $1 AND r.fire_at <= $2 AND e.deleted_at IS NULL AND e.date > $2 "+
+			"ORDER BY r.fire_at, e.id",
+		after.UTC(), until.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	var due []storage.DueReminder
+	for rows.Next() {
+		var (
+			item    storage.DueReminder
+			seconds int64
+		)
+
+		err := rows.Scan(
+			&item.Event.ID, &item.Event.Title, &item.Event.Description, &item.Event.Date, &item.Event.Duration,
+			&item.Event.UserID, &seconds, &item.Reminder.Channel, &item.Reminder.FireAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Reminder.EventID, item.Reminder.UserID = item.Event.ID, item.Event.UserID
+		item.Reminder.Offset = time.Duration(seconds) * time.Second
+		due = append(due, item)
+	}
+
+	return due, rows.Err()
+}
+
+// rescheduleReminders moves the reminders of an event whose start changed or
+// that came back from the trash.
+func rescheduleReminders(ctx context.Context, tx pgx.Tx, eventID int) error {
+	_, err := tx.Exec(
+		ctx,
+		"UPDATE event_reminders r SET fire_at = greatest(e.date - make_interval(secs => r.offset_seconds), $2) "+
+			"FROM events e WHERE e.id = r.event_id AND r.event_id = $1",
+		eventID, time.Now().UTC(),
+	)
+	return err
+}
@@ -0,0 +1,55 @@
+package sqlstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDueReminders(t *testing.T) {
+	ctx := context.Background()
+	s, userID := newTestStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	event := &storage.Event{UserID: userID, Title: "Review", Duration: "1:00:00", Date: now.Add(2 * time.Hour)}
+	require.NoError(t, s.AddEvent(ctx, event))
+	require.NoError(t, s.SetReminders(ctx, event.ID, userID, []storage.Reminder{
+		{Offset: 24 * time.Hour}, {Offset: time.Hour},
+	}))
+
+	offsets := func(after, until time.Time) []time.Duration {
+		due, err := s.DueReminders(ctx, after, until)
+		require.NoError(t, err)
+
+		var result []time.Duration
+		for _, item := range due {
+			if item.Event.UserID == userID {
+				require.Equal(t, event.ID, item.Event.ID)
+				result = append(result, item.Reminder.Offset)
+			}
+		}
+		return result
+	}
+
+	later := time.Now().Add(time.Second)
+	require.Equal(t, []time.Duration{24 * time.Hour}, offsets(now.Add(-time.Second), later),
+		"an overdue reminder fires at once")
+	require.Empty(t, offsets(later, now.Add(59*time.Minute)))
+	require.Equal(t, []time.Duration{time.Hour}, offsets(later, now.Add(time.Hour)))
+
+	t.Run("Moved With Event", func(t *testing.T) {
+		moved := &storage.Event{ID: event.ID, UserID: userID, Date: now.Add(3 * time.Hour)}
+		require.NoError(t, s.UpdateEvent(ctx, moved))
+
+		require.Empty(t, offsets(later, now.Add(time.Hour)))
+		require.Equal(t, []time.Duration{time.Hour}, offsets(later, now.Add(2*time.Hour)))
+	})
+
+	t.Run("Not For Deleted Events", func(t *testing.T) {
+		require.NoError(t, s.DeleteEvent(ctx, event.ID, userID))
+		require.Empty(t, offsets(later, now.Add(2*time.Hour)))
+	})
+}
@@ -2,20 +2,29 @@ package sqlstorage
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
 	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const eventColumns = "id, title, coalesce(description, ''), date, duration::text, user_id, deleted_at"
+
+var ErrEventNotFound = app.ErrEventNotFound
+
 type Closer interface {
 	Close(ctx context.Context) error
 }
 
+// Storage runs every query on a connection from a pool: a pgx.Conn is not safe
+// for concurrent use, and a query canceled by its context closes the connection
+// it ran on, which the pool then replaces.
 type Storage struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
 func New(ctx context.Context, conf config.DBConf) (*Storage, error) {
@@ -30,40 +39,67 @@ func New(ctx context.Context, conf config.DBConf) (*Storage, error) {
 }
 
 func (s *Storage) Connect(ctx context.Context, conf config.DBConf) error {
-	connString := "postgres://" + conf.Username + ":" + conf.Password + "@" + conf.Host + ":" + conf.Port + "/" + conf.Database
+	poolConf, err := pgxpool.ParseConfig(DSN(conf))
+	if err != nil {
+		return redact(err, conf)
+	}
+	poolConf.MaxConns = conf.MaxConns
 
-	conn, err := pgx.Connect(ctx, connString)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
 	if err != nil {
-		return err
+		return redact(err, conf)
+	}
+
+	// the pool connects lazily, so fail fast on bad settings like pgx.Connect did
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return redact(err, conf)
 	}
-	s.db = conn
+	s.db = pool
 
 	return nil
 }
 
-func (s *Storage) Close(ctx context.Context) error {
-	if s.db.IsClosed() {
-		return nil
-	}
+func (s *Storage) Close(_ context.Context) error {
+	s.db.Close()
+	return nil
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
+// MigrationVersion returns the newest goose migration applied to the database.
+func (s *Storage) MigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
 
-	err := s.db.Close(ctx)
+	err := s.db.QueryRow(
+		ctx,
+		"SELECT coalesce(max(version_id), 0) FROM goose_db_version WHERE is_applied",
+	).Scan(&version)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return version, nil
 }
 
 func (s *Storage) AddEvent(ctx context.Context, event *storage.Event) error {
-	_, err := s.db.Exec(
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		return insertEvent(ctx, tx, event)
+	})
+}
+
+func insertEvent(ctx context.Context, tx pgx.Tx, event *storage.Event) error {
+	err := tx.QueryRow(
 		ctx,
-		"INSERT INTO events (title, date, duration, user_id) VALUES ($1, $2, $3, $4)",
-		event.Title, event.Date, event.Duration, event.UserID)
+		"INSERT INTO events (title, description, date, duration, user_id) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		event.Title, event.Description, event.Date, event.Duration, event.UserID).Scan(&event.ID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return recordChange(ctx, tx, storage.ChangeCreated, storage.Event{}, event, 0)
 }
 
 func (s *Storage) UpdateEvent(ctx context.Context, updated *storage.Event) error {
@@ -71,24 +107,114 @@ func (s *Storage) UpdateEvent(ctx context.Context, updated *storage.Event) error
 		return app.ErrUserIDRequired
 	}
 
-	_, err := s.db.Exec(ctx, "UPDATE events SET title = $1, duration = $2, date = $3, WHERE id = $5 AND user_id = $6", updated.Title, updated.Duration, updated.Date, updated.ID, updated.UserID)
-	if err != nil {
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := updateEvent(ctx, tx, updated)
 		return err
+	})
+}
+
+func updateEvent(ctx context.Context, tx pgx.Tx, updated *storage.Event) (*storage.Event, error) {
+	before, err := lockEvent(ctx, tx, updated.ID, updated.UserID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// like the memory storage, an update only changes the fields that are set
+	patch := *before
+	if updated.Title != "" {
+		patch.Title = updated.Title
+	}
+	if updated.Description != "" {
+		patch.Description = updated.Description
+	}
+	if updated.Duration != "" {
+		patch.Duration = updated.Duration
+	}
+	if !updated.Date.IsZero() {
+		patch.Date = updated.Date
+	}
+
+	var event storage.Event
+	err = scanEvent(tx.QueryRow(
+		ctx,
+		"UPDATE events SET title = $1, description = $2, duration = $3, date = $4 WHERE id = $5 RETURNING "+eventColumns,
+		patch.Title, patch.Description, patch.Duration, patch.Date, patch.ID,
+	), &event)
+	if err != nil {
+		return nil, err
+	}
+	if !event.Date.Equal(before.Date) {
+		if err := rescheduleReminders(ctx, tx, event.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &event, recordChange(ctx, tx, storage.ChangeUpdated, *before, &event, 0)
 }
 
 func (s *Storage) DeleteEvent(ctx context.Context, id int, userID int) error {
-	_, err := s.db.Exec(
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := deleteEvent(ctx, tx, id, userID)
+		return err
+	})
+}
+
+func deleteEvent(ctx context.Context, tx pgx.Tx, id int, userID int) (*storage.Event, error) {
+	before, err := lockEvent(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var event storage.Event
+	err = scanEvent(tx.QueryRow(
 		ctx,
-		"DELETE FROM events WHERE id = $1 AND user_id = $2",
+		"UPDATE events SET deleted_at = $2 WHERE id = $1 RETURNING "+eventColumns,
+		id, time.Now().UTC(),
+	), &event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, recordChange(ctx, tx, storage.ChangeDeleted, *before, &event, 0)
+}
+
+// lockEvent loads a live event and holds its row lock until the transaction ends.
+func lockEvent(ctx context.Context, tx pgx.Tx, id int, userID int) (*storage.Event, error) {
+	var event storage.Event
+
+	if err := lockChanges(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	err := scanEvent(tx.QueryRow(
+		ctx,
+		"SELECT "+eventColumns+" FROM events WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE",
 		id, userID,
-	)
+	), &event)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (s *Storage) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (s *Storage) ListEvents(
@@ -98,7 +224,8 @@ func (s *Storage) ListEvents(
 
 	rows, err := s.db.Query(
 		ctx,
-		"SELECT id, title, date, duration::text, user_id FROM events WHERE user_id = $1 AND date >= $2 AND date <= $3",
+		"SELECT "+eventColumns+" FROM events "+
+			"WHERE user_id = $1 AND date >= $2 AND date <= $3 AND deleted_at IS NULL",
 		userID,
 		dateFrom,
 		dateTo,
@@ -111,7 +238,7 @@ func (s *Storage) ListEvents(
 	for rows.Next() {
 		var event storage.Event
 
-		err = rows.Scan(&event.ID, &event.Title, &event.Date, &event.Duration, &event.UserID)
+		err = scanEvent(rows, &event)
 		if err != nil {
 			return nil, err
 		}
@@ -121,3 +248,113 @@ func (s *Storage) ListEvents(
 
 	return events, nil
 }
+
+const listEventsPageQuery = `
+SELECT ` + eventColumns + `
+FROM events
+WHERE user_id = $1
+  AND deleted_at IS NULL
+  AND date >= $2 AND date <= $3
+  AND ($4::timestamp IS NULL OR (date, id) > ($4, $5))
+ORDER BY date, id
+LIMIT $6`
+
+func (s *Storage) ListEventsPage(ctx context.Context, query storage.ListQuery) ([]storage.Event, error) {
+	var (
+		events  []storage.Event
+		afterAt *time.Time
+		afterID int
+	)
+
+	if query.After != nil {
+		afterAt, afterID = &query.After.Date, query.After.ID
+	}
+
+	rows, err := s.db.Query(
+		ctx,
+		listEventsPageQuery,
+		query.UserID,
+		query.DateFrom,
+		query.DateTo,
+		afterAt,
+		afterID,
+		query.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var event storage.Event
+
+		err = scanEvent(rows, &event)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+const searchEventsQuery = `
+SELECT id, title, coalesce(description, ''), date, duration::text, user_id,
+       ts_rank(search_vector, query) AS rank
+FROM events, websearch_to_tsquery('simple', $2) query
+WHERE user_id = $1
+  AND deleted_at IS NULL
+  AND search_vector @@ query
+  AND ($3::timestamp IS NULL OR date >= $3)
+  AND ($4::timestamp IS NULL OR date <= $4)
+ORDER BY rank DESC, date, id
+LIMIT $5 OFFSET $6`
+
+func (s *Storage) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	var results []storage.SearchResult
+
+	rows, err := s.db.Query(
+		ctx,
+		searchEventsQuery,
+		query.UserID,
+		query.Text,
+		nullTime(query.DateFrom),
+		nullTime(query.DateTo),
+		query.Limit,
+		query.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var result storage.SearchResult
+		event := &result.Event
+
+		err = rows.Scan(
+			&event.ID, &event.Title, &event.Description, &event.Date, &event.Duration, &event.UserID, &result.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func scanEvent(row pgx.Row, event *storage.Event) error {
+	return row.Scan(
+		&event.ID, &event.Title, &event.Description, &event.Date, &event.Duration, &event.UserID, &event.DeletedAt,
+	)
+}
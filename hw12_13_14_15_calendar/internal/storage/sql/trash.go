@@ -0,0 +1,83 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) ListTrash(ctx context.Context, userID int) ([]storage.Event, error) {
+	var events []storage.Event
+
+	rows, err := s.db.Query(
+		ctx,
+		"SELECT "+eventColumns+" FROM events WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var event storage.Event
+
+		if err := scanEvent(rows, &event); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *Storage) RestoreEvent(ctx context.Context, id int, userID int, deletedAfter time.Time) error {
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		var event storage.Event
+
+		if err := lockChanges(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		err := scanEvent(tx.QueryRow(
+			ctx,
+			"SELECT "+eventColumns+" FROM events WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL FOR UPDATE",
+			id, userID,
+		), &event)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return app.ErrEventNotInTrash
+		}
+		if err != nil {
+			return err
+		}
+
+		if event.DeletedAt.Before(deletedAfter.UTC()) {
+			return app.ErrRestoreWindowExpired
+		}
+
+		if _, err := tx.Exec(ctx, "UPDATE events SET deleted_at = NULL WHERE id = $1", id); err != nil {
+			return err
+		}
+		if err := rescheduleReminders(ctx, tx, id); err != nil {
+			return err
+		}
+
+		before := event
+		event.DeletedAt = nil
+		return recordChange(ctx, tx, storage.ChangeRestored, before, &event, 0)
+	})
+}
+
+func (s *Storage) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	tag, err := s.db.Exec(ctx, "DELETE FROM events WHERE deleted_at < $1", deletedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,212 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	webhookColumns  = "id, user_id, url, secret, events, last_change_id, created_at"
+	deliveryColumns = "id, webhook_id, user_id, event_id, type, key, payload, status, attempts, " +
+		"response_code, error, trace_parent, next_attempt_at, created_at, updated_at"
+)
+
+func (s *Storage) AddWebhook(ctx context.Context, webhook *storage.Webhook) error {
+	webhook.CreatedAt = time.Now().UTC()
+
+	return s.db.QueryRow(
+		ctx,
+		"INSERT INTO webhooks (user_id, url, secret, events, last_change_id, created_at) "+
+			"VALUES ($1, $2, $3, $4, (SELECT coalesce(max(id), 0) FROM event_changes WHERE user_id = $1), $5) "+
+			"RETURNING id, last_change_id",
+		webhook.UserID, webhook.URL, webhook.Secret, changeTypesToStrings(webhook.Events), webhook.CreatedAt,
+	).Scan(&webhook.ID, &webhook.LastChangeID)
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context, userID int) ([]storage.Webhook, error) {
+	return s.queryWebhooks(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+}
+
+func (s *Storage) AllWebhooks(ctx context.Context) ([]storage.Webhook, error) {
+	return s.queryWebhooks(ctx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id")
+}
+
+func (s *Storage) queryWebhooks(ctx context.Context, query string, args ...any) ([]storage.Webhook, error) {
+	var webhooks []storage.Webhook
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			webhook storage.Webhook
+			events  []string
+		)
+
+		err = rows.Scan(
+			&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &events, &webhook.LastChangeID, &webhook.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			webhook.Events = append(webhook.Events, storage.ChangeType(event))
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id int, userID int) error {
+	tag, err := s.db.Exec(ctx, "DELETE FROM webhooks WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return app.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *Storage) EnqueueDeliveries(
+	ctx context.Context, webhookID int, lastChangeID int64, deliveries []storage.WebhookDelivery,
+) error {
+	now := time.Now().UTC()
+
+	return s.inTx(ctx, func(tx pgx.Tx) error {
+		for _, delivery := range deliveries {
+			_, err := tx.Exec(
+				ctx,
+				"INSERT INTO webhook_deliveries "+
+					"(webhook_id, user_id, event_id, type, key, payload, status, trace_parent, next_attempt_at, "+
+					"created_at, updated_at) "+
+					"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10) ON CONFLICT (webhook_id, key) DO NOTHING",
+				webhookID, delivery.UserID, delivery.EventID, delivery.Type, delivery.Key, delivery.Payload,
+				delivery.Status, delivery.TraceParent, delivery.NextAttemptAt.UTC(), now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		tag, err := tx.Exec(
+			ctx,
+			"UPDATE webhooks SET last_change_id = greatest(last_change_id, $1) WHERE id = $2",
+			lastChangeID, webhookID,
+		)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return app.ErrWebhookNotFound
+		}
+		return nil
+	})
+}
+
+func (s *Storage) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]storage.WebhookDelivery, error) {
+	return s.queryDeliveries(
+		ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries "+
+			"WHERE status = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at, id LIMIT $3",
+		storage.DeliveryPending, now.UTC(), limit,
+	)
+}
+
+func (s *Storage) PendingDeliveries(ctx context.Context) (int, error) {
+	var pending int
+
+	err := s.db.QueryRow(
+		ctx, "SELECT count(*) FROM webhook_deliveries WHERE status = $1", storage.DeliveryPending,
+	).Scan(&pending)
+	return pending, err
+}
+
+func (s *Storage) UpdateDelivery(ctx context.Context, delivery *storage.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now().UTC()
+
+	tag, err := s.db.Exec(
+		ctx,
+		"UPDATE webhook_deliveries SET status = $1, attempts = $2, response_code = $3, error = $4, "+
+			"next_attempt_at = $5, updated_at = $6 WHERE id = $7",
+		delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.Error,
+		delivery.NextAttemptAt.UTC(), delivery.UpdatedAt, delivery.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return app.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (s *Storage) ListWebhookDeliveries(
+	ctx context.Context, userID int, webhookID int, limit int,
+) ([]storage.WebhookDelivery, error) {
+	return s.queryDeliveries(
+		ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries "+
+			"WHERE user_id = $1 AND ($2 = 0 OR webhook_id = $2) ORDER BY id DESC LIMIT $3",
+		userID, webhookID, limit,
+	)
+}
+
+func (s *Storage) queryDeliveries(ctx context.Context, query string, args ...any) ([]storage.WebhookDelivery, error) {
+	var deliveries []storage.WebhookDelivery
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var delivery storage.WebhookDelivery
+
+		err = rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.UserID, &delivery.EventID, &delivery.Type, &delivery.Key,
+			&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseCode, &delivery.Error,
+			&delivery.TraceParent, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func changeTypesToStrings(types []storage.ChangeType) []string {
+	result := make([]string, 0, len(types))
+	for _, changeType := range types {
+		result = append(result, string(changeType))
+	}
+	return result
+}
+
+func (s *Storage) PruneDeliveries(ctx context.Context, before time.Time) (int, error) {
+	tag, err := s.db.Exec(
+		ctx,
+		"DELETE FROM webhook_deliveries WHERE status <> $1 AND updated_at < $2",
+		storage.DeliveryPending, before.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
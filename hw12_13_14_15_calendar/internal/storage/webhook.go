@@ -0,0 +1,54 @@
+package storage
+
+import "time"
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// ChangeStarting marks webhook deliveries for events that are about to start.
+const ChangeStarting ChangeType = "starting"
+
+type Webhook struct {
+	ID           int
+	UserID       int
+	URL          string
+	Secret       string
+	Events       []ChangeType
+	LastChangeID int64
+	CreatedAt    time.Time
+}
+
+func (w Webhook) Subscribed(changeType ChangeType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == changeType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDelivery struct {
+	ID            int
+	WebhookID     int
+	UserID        int
+	EventID       int
+	Type          ChangeType
+	Key           string
+	Payload       []byte
+	Status        DeliveryStatus
+	Attempts      int
+	ResponseCode  int
+	Error         string
+	TraceParent   string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterExporter writes every finished span as one JSON line.
+type WriterExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	exporter := &WriterExporter{encoder: json.NewEncoder(w)}
+	if closer, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+		exporter.closer = closer
+	}
+	return exporter
+}
+
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+func NewFileExporter(path string) (*WriterExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterExporter(file), nil
+}
+
+func (e *WriterExporter) Export(span SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.encoder.Encode(span)
+}
+
+func (e *WriterExporter) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// NewExporter builds the exporter selected in configuration; it returns a nil
+// exporter when tracing is disabled.
+func NewExporter(kind string, file string) (Exporter, error) {
+	switch kind {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return NewStdoutExporter(), nil
+	case ExporterFile:
+		return NewFileExporter(file)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, kind)
+	}
+}
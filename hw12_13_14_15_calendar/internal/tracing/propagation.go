@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context header used on HTTP requests,
+// webhook deliveries and change feed records.
+const TraceParentHeader = "traceparent"
+
+// TraceParent formats the span context in ctx as a W3C traceparent value, or
+// returns an empty string when ctx carries no trace.
+func TraceParent(ctx context.Context) string {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-01"
+}
+
+func ParseTraceParent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+
+	return sc, true
+}
+
+func decodeHex(value string, dst []byte) bool {
+	if len(value) != hex.EncodedLen(len(dst)) {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(value))
+	return err == nil
+}
+
+func Inject(ctx context.Context, header http.Header) {
+	if traceParent := TraceParent(ctx); traceParent != "" {
+		header.Set(TraceParentHeader, traceParent)
+	}
+}
+
+// Extract continues the trace described by a traceparent header, if any.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return ContextWithTraceParent(ctx, header.Get(TraceParentHeader))
+}
+
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if sc, ok := ParseTraceParent(traceParent); ok {
+		return ContextWithRemote(ctx, sc)
+	}
+	return ctx
+}
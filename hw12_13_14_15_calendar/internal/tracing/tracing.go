@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type SpanData struct {
+	TraceID    string         `json:"traceId"`
+	SpanID     string         `json:"spanId"`
+	ParentID   string         `json:"parentSpanId,omitempty"`
+	Name       string         `json:"name"`
+	Service    string         `json:"service"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Duration   float64        `json:"durationMs"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type Exporter interface {
+	Export(span SpanData) error
+	Close() error
+}
+
+type Tracer struct {
+	service  string
+	exporter Exporter
+	onError  func(err error)
+}
+
+type Span struct {
+	tracer  *Tracer
+	context SpanContext
+	parent  SpanID
+	name    string
+	start   time.Time
+	mu      sync.Mutex
+	attrs   map[string]any
+	err     error
+	ended   bool
+}
+
+type spanKey struct{}
+
+var global atomic.Pointer[Tracer]
+
+// NewTracer creates a tracer that hands finished spans to exporter; export
+// failures are passed to onError so they never break the traced call.
+func NewTracer(service string, exporter Exporter, onError func(err error)) *Tracer {
+	return &Tracer{service: service, exporter: exporter, onError: onError}
+}
+
+// SetTracer installs the process-wide tracer used by Start. Passing nil disables tracing.
+func SetTracer(tracer *Tracer) {
+	global.Store(tracer)
+}
+
+// Start opens a span as a child of the span (or remote context) stored in ctx.
+// With no tracer installed it returns ctx unchanged and a no-op span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	tracer := global.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+	return tracer.Start(ctx, name)
+}
+
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{tracer: t, name: name, start: time.Now()}
+
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		span.context.TraceID = parent.TraceID
+		span.parent = parent.SpanID
+	} else {
+		_, _ = rand.Read(span.context.TraceID[:])
+	}
+	_, _ = rand.Read(span.context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span.context), span
+}
+
+// ContextWithRemote marks sc, received from another process, as the parent of spans started from ctx.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End finishes the span, recording *err when it is set. It is meant to be
+// deferred with a pointer to the named error result of the traced function.
+func (s *Span) End(err *error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.RecordError(*err)
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+
+	end := time.Now()
+	data := SpanData{
+		TraceID:    s.context.TraceID.String(),
+		SpanID:     s.context.SpanID.String(),
+		Name:       s.name,
+		Service:    s.tracer.service,
+		Start:      s.start,
+		End:        end,
+		Duration:   float64(end.Sub(s.start).Microseconds()) / 1000,
+		Attributes: s.attrs,
+	}
+	if s.parent.IsValid() {
+		data.ParentID = s.parent.String()
+	}
+	if s.err != nil {
+		data.Error = s.err.Error()
+	}
+	s.mu.Unlock()
+
+	if err := s.tracer.exporter.Export(data); err != nil && s.tracer.onError != nil {
+		s.tracer.onError(err)
+	}
+}
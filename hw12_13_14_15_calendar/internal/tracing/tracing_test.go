@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpans(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer("test", NewWriterExporter(&buf), nil)
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+
+	childErr := errors.New("boom")
+	child.End(&childErr)
+	parent.End(nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var childData, parentData SpanData
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &childData))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &parentData))
+
+	require.Equal(t, parentData.TraceID, childData.TraceID)
+	require.Equal(t, parentData.SpanID, childData.ParentID)
+	require.Empty(t, parentData.ParentID)
+	require.Equal(t, "boom", childData.Error)
+	require.Equal(t, "test", childData.Service)
+}
+
+func TestPropagation(t *testing.T) {
+	tracer := NewTracer("test", NewWriterExporter(&bytes.Buffer{}), nil)
+	ctx, span := tracer.Start(context.Background(), "client")
+	defer span.End(nil)
+
+	header := http.Header{}
+	Inject(ctx, header)
+	require.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, header.Get(TraceParentHeader))
+
+	remote := Extract(context.Background(), header)
+	require.Equal(t, SpanContextFromContext(ctx), SpanContextFromContext(remote))
+
+	t.Run("Invalid Header", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"garbage",
+			"00-00000000000000000000000000000000-0000000000000000-01",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		} {
+			_, ok := ParseTraceParent(value)
+			require.False(t, ok, value)
+		}
+	})
+
+	t.Run("Disabled Tracer", func(t *testing.T) {
+		ctx, span := Start(context.Background(), "noop")
+		span.SetAttribute("key", "value")
+		span.End(nil)
+		require.Empty(t, TraceParent(ctx))
+	})
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrAddressNotAllowed = errors.New("destination address is not allowed")
+
+// internalNetworks are refused unless allowed in the config; IsLoopback,
+// IsPrivate and the link-local checks cover the rest.
+var internalNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// NewClient returns the HTTP client for user supplied URLs. It refuses to
+// connect to loopback, private, link-local and other internal addresses, such
+// as a cloud metadata service, unless they are inside one of the allowed IPs
+// or CIDRs. The check runs on the resolved address at dial time, so it also
+// holds for redirects and for host names that resolve differently later.
+// Proxies from the environment are not used, as the check would only see them.
+func NewClient(timeout time.Duration, allowed []string) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: guard(parseNetworks(allowed))}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
+
+// parseNetworks accepts IPs and CIDRs; the config validation reports bad ones.
+func parseNetworks(networks []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		if !strings.Contains(network, "/") {
+			if addr, err := netip.ParseAddr(network); err == nil {
+				prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			}
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(network); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+	return prefixes
+}
+
+func guard(allowed []netip.Prefix) func(network, address string, _ syscall.RawConn) error {
+	return func(_, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrAddressNotAllowed, address)
+		}
+
+		addr := addrPort.Addr().Unmap()
+		for _, prefix := range allowed {
+			if prefix.Contains(addr) {
+				return nil
+			}
+		}
+
+		if internal(addr) {
+			return fmt.Errorf("%w: %s", ErrAddressNotAllowed, addr)
+		}
+		return nil
+	}
+}
+
+func internal(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	for _, prefix := range internalNetworks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
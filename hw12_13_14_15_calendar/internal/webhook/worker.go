@@ -0,0 +1,363 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+const (
+	HeaderEvent     = "X-Calendar-Event"
+	HeaderDelivery  = "X-Calendar-Delivery"
+	HeaderTimestamp = "X-Calendar-Timestamp"
+	HeaderSignature = "X-Calendar-Signature"
+
+	maxErrorLength  = 512
+	defaultInterval = 5 * time.Second
+	queueName       = "webhooks"
+)
+
+type Logger interface {
+	Info(msg string, attrs ...any)
+	Error(msg string, attrs ...any)
+	Debug(msg string, attrs ...any)
+	Warn(msg string, attrs ...any)
+}
+
+type Storage interface {
+	AllWebhooks(ctx context.Context) ([]storage.Webhook, error)
+	ListChanges(ctx context.Context, userID int, after int64, limit int) ([]storage.Change, error)
+	ListEvents(ctx context.Context, userID int, dateFrom time.Time, dateTo time.Time) ([]storage.Event, error)
+	EnqueueDeliveries(ctx context.Context, webhookID int, lastChangeID int64, deliveries []storage.WebhookDelivery) error
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]storage.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *storage.WebhookDelivery) error
+	PendingDeliveries(ctx context.Context) (int, error)
+	PruneDeliveries(ctx context.Context, before time.Time) (int, error)
+}
+
+type Metrics interface {
+	SetQueueDepth(queue string, depth int)
+	ObserveDelivery(queue, outcome string)
+}
+
+type Worker struct {
+	logger  Logger
+	storage Storage
+	metrics Metrics
+	mu      sync.RWMutex
+	conf    config.WebhookConf
+	client  *http.Client
+	now     func() time.Time
+}
+
+type Payload struct {
+	Type       string       `json:"type"`
+	OccurredAt time.Time    `json:"occurredAt"`
+	Event      PayloadEvent `json:"event"`
+}
+
+type PayloadEvent struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Date        time.Time `json:"date"`
+	Duration    string    `json:"duration"`
+	UserID      int       `json:"userId"`
+}
+
+func New(logger Logger, storage Storage, conf config.WebhookConf, metrics Metrics) *Worker {
+	worker := &Worker{
+		logger:  logger,
+		storage: storage,
+		metrics: metrics,
+		now:     time.Now,
+	}
+	worker.SetConfig(conf)
+
+	return worker
+}
+
+// SetConfig replaces the delivery settings; it is safe to call while the worker runs.
+func (w *Worker) SetConfig(conf config.WebhookConf) {
+	if conf.Interval <= 0 {
+		conf.Interval = defaultInterval
+	}
+
+	client := NewClient(conf.Timeout, conf.AllowedNetworks)
+
+	w.mu.Lock()
+	previous := w.client
+	w.conf = conf
+	w.client = client
+	w.mu.Unlock()
+
+	// keep-alive connections of the replaced client would stay open until they time out
+	if previous != nil {
+		previous.CloseIdleConnections()
+	}
+}
+
+func (w *Worker) config() config.WebhookConf {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.conf
+}
+
+func (w *Worker) httpClient() *http.Client {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.client
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	interval := w.config().Interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if current := w.config().Interval; current != interval {
+			interval = current
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// Tick turns new changes and upcoming events into deliveries and then sends
+// every delivery that is due.
+func (w *Worker) Tick(ctx context.Context) {
+	webhooks, err := w.storage.AllWebhooks(ctx)
+	if err != nil {
+		w.logger.Error("failed to load webhooks: " + err.Error())
+		return
+	}
+
+	byID := make(map[int]storage.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+
+		if err := w.dispatch(ctx, webhook); err != nil {
+			w.logger.Error("failed to enqueue webhook deliveries: "+err.Error(), "webhook", webhook.ID)
+		}
+	}
+
+	if err := w.deliverDue(ctx, byID); err != nil {
+		w.logger.Error("failed to deliver webhooks: " + err.Error())
+	}
+
+	// Retention outlasts the starting lead, so a starting delivery's key is kept until its event has started.
+	if _, err := w.storage.PruneDeliveries(ctx, w.now().Add(-w.config().Retention)); err != nil {
+		w.logger.Error("failed to prune webhook deliveries: " + err.Error())
+	}
+
+	pending, err := w.storage.PendingDeliveries(ctx)
+	if err != nil {
+		w.logger.Error("failed to count pending webhook deliveries: " + err.Error())
+		return
+	}
+	w.metrics.SetQueueDepth(queueName, pending)
+}
+
+func (w *Worker) dispatch(ctx context.Context, webhook storage.Webhook) error {
+	now := w.now()
+
+	changes, err := w.storage.ListChanges(ctx, webhook.UserID, webhook.LastChangeID, w.config().BatchSize)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []storage.WebhookDelivery
+	lastChangeID := webhook.LastChangeID
+
+	for _, change := range changes {
+		lastChangeID = change.ID
+		if !webhook.Subscribed(change.Type) {
+			continue
+		}
+
+		key := "change:" + strconv.FormatInt(change.ID, 10)
+		delivery, err := newDelivery(change.Type, key, change.Event, change.CreatedAt, now)
+		if err != nil {
+			return err
+		}
+		delivery.TraceParent = change.TraceParent
+		deliveries = append(deliveries, delivery)
+	}
+
+	if webhook.Subscribed(storage.ChangeStarting) {
+		events, err := w.storage.ListEvents(ctx, webhook.UserID, now, now.Add(w.config().StartingLead))
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			key := fmt.Sprintf("starting:%d:%d", event.ID, event.Date.Unix())
+			delivery, err := newDelivery(storage.ChangeStarting, key, event, now, now)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	if len(deliveries) == 0 && lastChangeID == webhook.LastChangeID {
+		return nil
+	}
+
+	return w.storage.EnqueueDeliveries(ctx, webhook.ID, lastChangeID, deliveries)
+}
+
+func newDelivery(
+	changeType storage.ChangeType, key string, event storage.Event, occurredAt time.Time, now time.Time,
+) (storage.WebhookDelivery, error) {
+	payload, err := json.Marshal(Payload{
+		Type:       EventName(changeType),
+		OccurredAt: occurredAt,
+		Event: PayloadEvent{
+			ID:          event.ID,
+			Title:       event.Title,
+			Description: event.Description,
+			Date:        event.Date,
+			Duration:    event.Duration,
+			UserID:      event.UserID,
+		},
+	})
+	if err != nil {
+		return storage.WebhookDelivery{}, err
+	}
+
+	return storage.WebhookDelivery{
+		UserID:        event.UserID,
+		EventID:       event.ID,
+		Type:          changeType,
+		Key:           key,
+		Payload:       payload,
+		Status:        storage.DeliveryPending,
+		NextAttemptAt: now,
+	}, nil
+}
+
+func (w *Worker) deliverDue(ctx context.Context, webhooks map[int]storage.Webhook) error {
+	deliveries, err := w.storage.DueDeliveries(ctx, w.now(), w.config().BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			delivery.Status = storage.DeliveryFailed
+			delivery.Error = "webhook was deleted"
+		} else {
+			w.attempt(ctx, webhook, delivery)
+		}
+
+		if err := w.storage.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Worker) attempt(ctx context.Context, webhook storage.Webhook, delivery *storage.WebhookDelivery) {
+	delivery.Attempts++
+
+	ctx, span := tracing.Start(tracing.ContextWithTraceParent(ctx, delivery.TraceParent), "webhook.deliver")
+	span.SetAttribute("webhook.id", webhook.ID)
+	span.SetAttribute("webhook.delivery", delivery.ID)
+	span.SetAttribute("webhook.attempt", delivery.Attempts)
+
+	code, err := w.send(ctx, webhook, delivery)
+	delivery.ResponseCode = code
+	span.SetAttribute("http.status_code", code)
+	span.End(&err)
+
+	if err == nil {
+		delivery.Status = storage.DeliveryDelivered
+		delivery.Error = ""
+		w.metrics.ObserveDelivery(queueName, string(storage.DeliveryDelivered))
+		w.logger.Debug("webhook delivered", "webhook", webhook.ID, "delivery", delivery.ID)
+		return
+	}
+
+	delivery.Error = err.Error()
+	if len(delivery.Error) > maxErrorLength {
+		delivery.Error = delivery.Error[:maxErrorLength]
+	}
+
+	conf := w.config()
+	if delivery.Attempts >= conf.MaxAttempts {
+		delivery.Status = storage.DeliveryFailed
+		w.metrics.ObserveDelivery(queueName, string(storage.DeliveryFailed))
+		w.logger.Error("webhook delivery failed permanently: "+err.Error(),
+			"webhook", webhook.ID, "delivery", delivery.ID, "attempts", delivery.Attempts)
+		return
+	}
+
+	w.metrics.ObserveDelivery(queueName, "retry")
+	delivery.NextAttemptAt = w.now().Add(Backoff(conf.BackoffBase, conf.BackoffMax, delivery.Attempts))
+	w.logger.Warn("webhook delivery failed, will retry: "+err.Error(),
+		"webhook", webhook.ID, "delivery", delivery.ID, "attempts", delivery.Attempts,
+		"nextAttemptAt", delivery.NextAttemptAt)
+}
+
+func (w *Worker) send(ctx context.Context, webhook storage.Webhook, delivery *storage.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, EventName(delivery.Type))
+	request.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+	tracing.Inject(ctx, request.Header)
+
+	response, err := w.httpClient().Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return response.StatusCode, fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+func EventName(changeType storage.ChangeType) string {
+	return "event." + string(changeType)
+}
+
+func Backoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if maxDelay > 0 && delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return delay
+}
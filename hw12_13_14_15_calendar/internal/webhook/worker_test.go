@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	payloads []Payload
+	failures int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	if !Verify("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload Payload
+	_ = json.Unmarshal(body, &payload)
+	rc.payloads = append(rc.payloads, payload)
+}
+
+func (rc *receiver) received() []Payload {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]Payload(nil), rc.payloads...)
+}
+
+func TestWorker(t *testing.T) {
+	ctx := context.Background()
+	rc := &receiver{failures: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	hook := &storage.Webhook{UserID: 1, URL: server.URL, Secret: "secret"}
+	require.NoError(t, storageService.AddWebhook(ctx, hook))
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	worker := New(logg, storageService, config.WebhookConf{
+		StartingLead: time.Hour,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		BackoffBase:  time.Minute,
+		BackoffMax:   time.Hour,
+		BatchSize:    10,
+		Retention:    24 * time.Hour,
+		// the receiver listens on loopback, which deliveries may not reach by default
+		AllowedNetworks: []string{"127.0.0.1"},
+	}, metrics.New())
+	worker.now = func() time.Time { return now }
+
+	event := &storage.Event{UserID: 1, Title: "Retro", Duration: "1:00:00", Date: now.Add(10 * time.Minute)}
+	require.NoError(t, storageService.AddEvent(ctx, event))
+
+	worker.Tick(ctx)
+
+	deliveries, err := storageService.ListWebhookDeliveries(ctx, 1, hook.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	require.Len(t, rc.received(), 1)
+
+	failed := deliveries[1]
+	require.Equal(t, storage.DeliveryPending, failed.Status)
+	require.Equal(t, 1, failed.Attempts)
+	require.Equal(t, http.StatusInternalServerError, failed.ResponseCode)
+	require.Equal(t, now.Add(time.Minute), failed.NextAttemptAt)
+
+	t.Run("Retry Waits For Backoff", func(t *testing.T) {
+		worker.Tick(ctx)
+		require.Len(t, rc.received(), 1)
+
+		now = now.Add(time.Minute)
+		worker.Tick(ctx)
+		require.Len(t, rc.received(), 2)
+
+		deliveries, err := storageService.ListWebhookDeliveries(ctx, 1, hook.ID, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2, "starting notification must not be enqueued twice")
+		for _, delivery := range deliveries {
+			require.Equal(t, storage.DeliveryDelivered, delivery.Status)
+		}
+	})
+
+	t.Run("Payload Types", func(t *testing.T) {
+		types := map[string]bool{}
+		for _, payload := range rc.received() {
+			types[payload.Type] = true
+			require.Equal(t, event.ID, payload.Event.ID)
+		}
+		require.Equal(t, map[string]bool{"event.created": true, "event.starting": true}, types)
+	})
+
+	t.Run("Finished Deliveries Expire", func(t *testing.T) {
+		pruned, err := storageService.PruneDeliveries(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, 2, pruned)
+
+		deliveries, err := storageService.ListWebhookDeliveries(ctx, 1, hook.ID, 0)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+}
+
+func TestSetConfigClosesIdleConnections(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+	conf := config.WebhookConf{Timeout: time.Second, AllowedNetworks: []string{"127.0.0.1"}}
+	worker := New(logg, nil, conf, metrics.New())
+
+	response, err := worker.httpClient().Get(server.URL)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+
+	worker.SetConfig(conf)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the replaced client kept its keep-alive connection")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second, nil).Get(server.URL)
+	require.ErrorIs(t, err, ErrAddressNotAllowed)
+
+	response, err := NewClient(time.Second, []string{"127.0.0.0/8"}).Get(server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	for _, address := range []string{
+		"127.0.0.1:80", "[::1]:80", "10.1.2.3:80", "192.168.0.1:443", "169.254.169.254:80",
+		"[::ffff:169.254.169.254]:80", "[fd00::1]:80", "0.0.0.0:80", "100.64.0.1:80",
+	} {
+		require.ErrorIs(t, guard(nil)("tcp", address, nil), ErrAddressNotAllowed, address)
+	}
+	require.NoError(t, guard(nil)("tcp", "93.184.216.34:443", nil))
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Second, Backoff(time.Second, time.Minute, 1))
+	require.Equal(t, 8*time.Second, Backoff(time.Second, time.Minute, 4))
+	require.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 10))
+}
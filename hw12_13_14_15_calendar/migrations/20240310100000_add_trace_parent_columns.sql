@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event_changes ADD COLUMN trace_parent TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_deliveries ADD COLUMN trace_parent TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries DROP COLUMN trace_parent;
+ALTER TABLE event_changes DROP COLUMN trace_parent;
+-- +goose StatementEnd
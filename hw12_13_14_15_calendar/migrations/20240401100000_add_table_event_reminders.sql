@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE event_reminders (
+    event_id INTEGER NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    offset_seconds BIGINT NOT NULL,
+    channel TEXT NOT NULL DEFAULT '',
+    fire_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, offset_seconds, channel)
+);
+
+CREATE INDEX event_reminders_fire_at_idx ON event_reminders (fire_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_reminders;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+package calendarclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+var ErrNotReady = errors.New("calendar is not ready")
+
+// Ready reports per-check readiness; it returns ErrNotReady along with the
+// report when a check fails and does not retry.
+func (c *Client) Ready(ctx context.Context) (*HealthReport, error) {
+	var report HealthReport
+	err := c.do(ctx, call{
+		method: http.MethodGet, path: "/readyz", out: &report,
+		accept: []int{http.StatusServiceUnavailable},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Status != "ok" {
+		return &report, ErrNotReady
+	}
+	return &report, nil
+}
+
+func (c *Client) LogLevels(ctx context.Context) (*LogLevels, error) {
+	var levels LogLevels
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/admin/log-level", out: &levels}); err != nil {
+		return nil, err
+	}
+	return &levels, nil
+}
+
+// SetLogLevel sets the level of a package, or the root level when pkg is empty.
+func (c *Client) SetLogLevel(ctx context.Context, pkg string, level string) (*LogLevels, error) {
+	request := struct {
+		Package string `json:"package,omitempty"`
+		Level   string `json:"level"`
+	}{Package: pkg, Level: level}
+
+	var levels LogLevels
+	if err := c.do(ctx, call{method: http.MethodPut, path: "/admin/log-level", body: request, out: &levels}); err != nil {
+		return nil, err
+	}
+	return &levels, nil
+}
+
+// ResetLogLevel drops the package override so the package follows the root level again.
+func (c *Client) ResetLogLevel(ctx context.Context, pkg string) (*LogLevels, error) {
+	var levels LogLevels
+	err := c.do(ctx, call{
+		method: http.MethodDelete, path: "/admin/log-level", query: url.Values{"package": {pkg}}, out: &levels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &levels, nil
+}
@@ -0,0 +1,35 @@
+package calendarclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var ErrBatchRolledBack = errors.New("atomic batch rolled back")
+
+// ApplyBatch runs the operations in one transaction. In Atomic mode a failing
+// operation rolls back the others and ErrBatchRolledBack is returned along
+// with the report naming the failures.
+func (c *Client) ApplyBatch(
+	ctx context.Context, userID int, mode BatchMode, operations []BatchOperation,
+) (*BatchReport, error) {
+	request := struct {
+		Mode       BatchMode        `json:"mode"`
+		Operations []BatchOperation `json:"operations"`
+	}{Mode: mode, Operations: operations}
+
+	var report BatchReport
+	err := c.do(ctx, call{
+		method: http.MethodPost, path: "/events/batch", query: userQuery(userID), body: request, out: &report,
+		accept: []int{http.StatusUnprocessableEntity},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == Atomic && report.Failed > 0 {
+		return &report, ErrBatchRolledBack
+	}
+	return &report, nil
+}
@@ -0,0 +1,118 @@
+package calendarclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxEventSize = 1 << 20
+
+var ErrStreamClosed = errors.New("change stream closed")
+
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// StreamChanges calls handle for every change of the user after the given
+// change id until ctx is done or handle fails. Dropped connections are resumed
+// from the last handled change, with the client's retries and backoff applied
+// to consecutive failures.
+func (c *Client) StreamChanges(ctx context.Context, userID int, after int64, handle func(change Change) error) error {
+	failures := 0
+	for {
+		received, err := c.stream(ctx, userID, &after, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var handlerErr *handlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !c.retryable(http.MethodGet, apiErr.StatusCode) {
+			return err
+		}
+
+		if received {
+			failures = 0
+		}
+		if failures >= c.retries {
+			return err
+		}
+
+		delay := c.delay(failures)
+		if apiErr != nil && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		failures++
+		if err := c.wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) stream(
+	ctx context.Context, userID int, after *int64, handle func(change Change) error,
+) (received bool, err error) {
+	header := http.Header{"Accept": {"text/event-stream"}}
+	if *after > 0 {
+		header.Set("Last-Event-ID", strconv.FormatInt(*after, 10))
+	}
+
+	request := call{method: http.MethodGet, path: "/events/changes", query: userQuery(userID), header: header}
+	response, err := c.send(ctx, request, nil)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return false, newAPIError(response)
+	}
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventSize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data.WriteString(strings.TrimPrefix(value, " "))
+			}
+			continue
+		}
+		if data.Len() == 0 {
+			continue
+		}
+
+		var change Change
+		if err := json.Unmarshal([]byte(data.String()), &change); err != nil {
+			return received, fmt.Errorf("decode change: %w", err)
+		}
+		data.Reset()
+
+		if err := handle(change); err != nil {
+			return received, &handlerError{err: err}
+		}
+		received = true
+		*after = change.ID
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return received, err
+	}
+	return received, ErrStreamClosed
+}
@@ -0,0 +1,284 @@
+// Package calendarclient is a typed client for the calendar HTTP API
+// described by /openapi.json.
+package calendarclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries    = 3
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+
+	actorHeader = "X-Actor"
+)
+
+var ErrInvalidBaseURL = errors.New("invalid base url")
+
+// APIError is a non-2xx response of the API.
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("calendar api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("calendar api: %d %s", e.StatusCode, e.Message)
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	actor      string
+	adminToken string
+}
+
+type Option func(c *Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a failed request is retried, 0 disables retries.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// WithBackoff sets the first retry delay and the longest one; delays double in between.
+func WithBackoff(base, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.backoff = base
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithActor names who performs changes in event history. The server only
+// accepts it from its trusted proxies and records the user otherwise, so it is
+// meant for gateways that authenticate callers themselves.
+func WithActor(actor string) Option {
+	return func(c *Client) {
+		c.actor = actor
+	}
+}
+
+// WithAdminToken sets the bearer token the server requires for the log level calls.
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidBaseURL, baseURL)
+	}
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+
+	client := &Client{
+		baseURL:    parsed,
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	out    any
+	// accept lists error statuses whose body is still decoded into out.
+	accept []int
+}
+
+// do sends the call, retrying network errors and 502, 503 and 504 responses
+// of idempotent methods and 429 responses of any method, since a rate limited
+// request never reaches the handler.
+func (c *Client) do(ctx context.Context, call call) error {
+	var body []byte
+	if call.body != nil {
+		var err error
+		if body, err = json.Marshal(call.body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := c.send(ctx, call, body)
+		if err != nil {
+			if ctx.Err() != nil || !idempotent(call.method) || attempt >= c.retries {
+				return err
+			}
+			if err := c.wait(ctx, c.delay(attempt)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = c.handle(response, call)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !c.retryable(call.method, apiErr.StatusCode) || attempt >= c.retries {
+			return err
+		}
+
+		delay := c.delay(attempt)
+		if apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		if err := c.wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, call call, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, call.method, c.url(call.path, call.query), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range call.header {
+		request.Header[key] = values
+	}
+	if request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", "application/json")
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.actor != "" {
+		request.Header.Set(actorHeader, c.actor)
+	}
+	if c.adminToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	return c.httpClient.Do(request)
+}
+
+func (c *Client) handle(response *http.Response, call call) error {
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest && !accepted(call.accept, response.StatusCode) {
+		return newAPIError(response)
+	}
+
+	if call.out == nil || response.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(call.out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", call.method, call.path, err)
+	}
+	return nil
+}
+
+func newAPIError(response *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: response.StatusCode}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err == nil {
+		apiErr.Message = body.Error
+	}
+
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+func (c *Client) retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	default:
+		return false
+	}
+}
+
+func (c *Client) delay(attempt int) time.Duration {
+	delay := c.backoff
+	for i := 0; i < attempt && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.maxBackoff {
+		return c.maxBackoff
+	}
+	return delay
+}
+
+func (c *Client) wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func accepted(statuses []int, status int) bool {
+	for _, accepted := range statuses {
+		if accepted == status {
+			return true
+		}
+	}
+	return false
+}
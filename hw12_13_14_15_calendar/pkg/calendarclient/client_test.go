@@ -0,0 +1,274 @@
+package calendarclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/app"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/config"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/health"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/metrics"
+	internalhttp "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/server/http"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/RealAyyo/ayyo_go/hw12_13_14_15_calendar/pkg/calendarclient"
+	"github.com/stretchr/testify/require"
+)
+
+var errStop = errors.New("stop")
+
+func newCalendar(t *testing.T) (*httptest.Server, *memorystorage.Storage) {
+	t.Helper()
+
+	storageService, err := memorystorage.New()
+	require.NoError(t, err)
+
+	logg, err := logger.New(config.LoggerConf{Level: "ERROR"})
+	require.NoError(t, err)
+
+	calendar := app.New(logg, storageService, config.AppConf{
+		Pagination: config.PaginationConf{Secret: "test", DefaultSize: 2, MaxSize: 10},
+		ChangeFeed: config.ChangeFeedConf{PollInterval: 10 * time.Millisecond, BatchSize: 10},
+		Trash:      config.TrashConf{RestoreWindow: time.Hour},
+	})
+
+	// trusting loopback lets the client name the actor, as a gateway in front of the API would
+	conf := config.HTTPConf{TrustedProxies: []string{"127.0.0.1"}, AdminToken: "admin"}
+	server, err := internalhttp.NewServer(logg, calendar, conf, metrics.New(), health.New(0), logg)
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+	return httpServer, storageService
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	httpServer, storageService := newCalendar(t)
+
+	day := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		event := &storage.Event{UserID: 1, Title: "Standup", Duration: "0:15:00", Date: day.Add(time.Duration(i) * time.Hour)}
+		require.NoError(t, storageService.AddEvent(ctx, event))
+	}
+
+	client, err := calendarclient.New(
+		httpServer.URL, calendarclient.WithActor("tests"), calendarclient.WithAdminToken("admin"),
+	)
+	require.NoError(t, err)
+
+	t.Run("List Events", func(t *testing.T) {
+		var titles []string
+		cursor := ""
+		for {
+			page, err := client.ListEvents(ctx, 1, day, calendarclient.Day, cursor, 0)
+			require.NoError(t, err)
+			for _, event := range page.Events {
+				titles = append(titles, event.Title)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		require.Len(t, titles, 3)
+	})
+
+	t.Run("Trash And Restore", func(t *testing.T) {
+		require.NoError(t, client.DeleteEvent(ctx, 1, 1))
+
+		trash, err := client.ListTrash(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		require.NotNil(t, trash[0].DeletedAt)
+
+		require.NoError(t, client.RestoreEvent(ctx, 1, 1))
+
+		history, err := client.EventHistory(ctx, 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, "tests", history[len(history)-1].Actor)
+	})
+
+	t.Run("API Error", func(t *testing.T) {
+		err := client.DeleteEvent(ctx, 42, 1)
+
+		var apiErr *calendarclient.APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		require.NotEmpty(t, apiErr.Message)
+	})
+
+	t.Run("Webhooks", func(t *testing.T) {
+		webhook, err := client.CreateWebhook(ctx, calendarclient.WebhookRequest{UserID: 1, URL: "http://example.com/hook"})
+		require.NoError(t, err)
+		require.NotEmpty(t, webhook.Secret)
+
+		webhooks, err := client.ListWebhooks(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		require.Empty(t, webhooks[0].Secret)
+
+		require.NoError(t, client.DeleteWebhook(ctx, webhook.ID, 1))
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		create := func(title string) calendarclient.BatchOperation {
+			return calendarclient.BatchOperation{
+				Op:    calendarclient.OpCreate,
+				Event: &calendarclient.EventFields{Title: title, Date: day, Duration: "1:00:00"},
+			}
+		}
+
+		report, err := client.ApplyBatch(ctx, 2, calendarclient.Partial, []calendarclient.BatchOperation{
+			create("Planning"), create("Retro"), {Op: calendarclient.OpDelete, ID: 42},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Applied)
+		require.NotZero(t, report.Results[0].Event.ID)
+		require.NotEmpty(t, report.Results[2].Error)
+
+		planning := report.Results[0].Event.ID
+		report, err = client.ApplyBatch(ctx, 2, calendarclient.Atomic, []calendarclient.BatchOperation{
+			{Op: calendarclient.OpUpdate, ID: planning, Event: &calendarclient.EventFields{Title: "Sprint planning"}},
+			{Op: calendarclient.OpDelete, ID: 42},
+		})
+		require.ErrorIs(t, err, calendarclient.ErrBatchRolledBack)
+		require.Equal(t, 0, report.Applied)
+
+		page, err := client.ListEvents(ctx, 2, day, calendarclient.Day, "", 10)
+		require.NoError(t, err)
+		require.Len(t, page.Events, 2)
+		require.ElementsMatch(t, []string{"Planning", "Retro"}, []string{page.Events[0].Title, page.Events[1].Title})
+	})
+
+	t.Run("Log Levels", func(t *testing.T) {
+		levels, err := client.SetLogLevel(ctx, "http", "debug")
+		require.NoError(t, err)
+		require.Equal(t, "DEBUG", levels.Packages["http"])
+
+		levels, err = client.ResetLogLevel(ctx, "http")
+		require.NoError(t, err)
+		require.NotContains(t, levels.Packages, "http")
+
+		anonymous, err := calendarclient.New(httpServer.URL)
+		require.NoError(t, err)
+		_, err = anonymous.SetLogLevel(ctx, "http", "debug")
+		var apiErr *calendarclient.APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		report, err := client.Ready(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "ok", report.Status)
+	})
+
+	t.Run("Stream Changes", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		var changes []calendarclient.Change
+		err := client.StreamChanges(ctx, 1, 0, func(change calendarclient.Change) error {
+			changes = append(changes, change)
+			if len(changes) == 3 {
+				return errStop
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, errStop)
+
+		var resumed []calendarclient.Change
+		err = client.StreamChanges(ctx, 1, changes[1].ID, func(change calendarclient.Change) error {
+			resumed = append(resumed, change)
+			return errStop
+		})
+		require.ErrorIs(t, err, errStop)
+		require.Equal(t, changes[2].ID, resumed[0].ID)
+	})
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+
+	flaky := func(failures int32, status int) (*httptest.Server, *atomic.Int32) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= failures {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"error":"try again"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"level":"INFO","packages":{}}`))
+		}))
+		t.Cleanup(server.Close)
+		return server, &calls
+	}
+
+	newClient := func(t *testing.T, url string, opts ...calendarclient.Option) *calendarclient.Client {
+		t.Helper()
+		opts = append([]calendarclient.Option{calendarclient.WithBackoff(time.Millisecond, 5*time.Millisecond)}, opts...)
+		client, err := calendarclient.New(url, opts...)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("Idempotent Request Retried", func(t *testing.T) {
+		server, calls := flaky(2, http.StatusServiceUnavailable)
+
+		levels, err := newClient(t, server.URL).LogLevels(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "INFO", levels.Level)
+		require.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Retries Exhausted", func(t *testing.T) {
+		server, calls := flaky(10, http.StatusBadGateway)
+
+		_, err := newClient(t, server.URL, calendarclient.WithRetries(2)).LogLevels(ctx)
+
+		var apiErr *calendarclient.APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+		require.Equal(t, "try again", apiErr.Message)
+		require.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Unsafe Request Not Retried", func(t *testing.T) {
+		server, calls := flaky(1, http.StatusServiceUnavailable)
+
+		err := newClient(t, server.URL).RestoreEvent(ctx, 1, 1)
+		require.Error(t, err)
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Rate Limited Request Retried", func(t *testing.T) {
+		server, calls := flaky(1, http.StatusTooManyRequests)
+
+		require.NoError(t, newClient(t, server.URL).RestoreEvent(ctx, 1, 1))
+		require.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Context Cancels Backoff", func(t *testing.T) {
+		server, _ := flaky(10, http.StatusServiceUnavailable)
+		client := newClient(t, server.URL, calendarclient.WithBackoff(time.Hour, time.Hour))
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.LogLevels(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := calendarclient.New("localhost:8888")
+	require.ErrorIs(t, err, calendarclient.ErrInvalidBaseURL)
+}
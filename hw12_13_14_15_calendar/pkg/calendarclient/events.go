@@ -0,0 +1,75 @@
+package calendarclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ListEvents returns a page of the user's events in the day, week or month
+// starting at date; pass the previous page's NextCursor to continue.
+func (c *Client) ListEvents(
+	ctx context.Context, userID int, date time.Time, dateRange Range, cursor string, limit int,
+) (*EventsPage, error) {
+	query := userQuery(userID)
+	query.Set("date", date.Format(dateLayout))
+	query.Set("range", string(dateRange))
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var page EventsPage
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/events", query: query, out: &page}); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// DeleteEvent moves the event to trash.
+func (c *Client) DeleteEvent(ctx context.Context, id int, userID int) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: "/events", query: eventQuery(id, userID)})
+}
+
+func (c *Client) ListTrash(ctx context.Context, userID int) ([]Event, error) {
+	var events []Event
+	err := c.do(ctx, call{method: http.MethodGet, path: "/events/trash", query: userQuery(userID), out: &events})
+	return events, err
+}
+
+func (c *Client) RestoreEvent(ctx context.Context, id int, userID int) error {
+	return c.do(ctx, call{method: http.MethodPost, path: "/events/restore", query: eventQuery(id, userID)})
+}
+
+func (c *Client) EventHistory(ctx context.Context, id int, userID int) ([]Revision, error) {
+	var revisions []Revision
+	err := c.do(ctx, call{method: http.MethodGet, path: "/events/history", query: eventQuery(id, userID), out: &revisions})
+	return revisions, err
+}
+
+func (c *Client) RevertEvent(ctx context.Context, id int, userID int, revision int) (*Event, error) {
+	query := eventQuery(id, userID)
+	query.Set("revision", strconv.Itoa(revision))
+
+	var event Event
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/events/revert", query: query, out: &event}); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func userQuery(userID int) url.Values {
+	return url.Values{"user_id": {strconv.Itoa(userID)}}
+}
+
+func eventQuery(id int, userID int) url.Values {
+	query := userQuery(userID)
+	query.Set("id", strconv.Itoa(id))
+	return query
+}
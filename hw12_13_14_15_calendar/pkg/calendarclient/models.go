@@ -0,0 +1,177 @@
+package calendarclient
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Range string
+
+const (
+	Day   Range = "day"
+	Week  Range = "week"
+	Month Range = "month"
+)
+
+type Event struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Date        time.Time  `json:"date"`
+	Duration    string     `json:"duration"`
+	UserID      int        `json:"userId"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+type EventsPage struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type Revision struct {
+	Revision     int           `json:"revision"`
+	Action       string        `json:"action"`
+	Actor        string        `json:"actor"`
+	Diff         []FieldChange `json:"diff"`
+	Snapshot     Event         `json:"snapshot"`
+	RevertedFrom int           `json:"revertedFrom,omitempty"`
+	CreatedAt    time.Time     `json:"createdAt"`
+}
+
+type Change struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	EventID   int       `json:"eventId"`
+	UserID    int       `json:"userId"`
+	Event     Event     `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WebhookRequest struct {
+	UserID int      `json:"userId"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Delivery struct {
+	ID            int             `json:"id"`
+	WebhookID     int             `json:"webhookId"`
+	EventID       int             `json:"eventId"`
+	Type          string          `json:"type"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"responseCode,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+type LogLevels struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+type CheckResult struct {
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"durationMs"`
+}
+
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type Preferences struct {
+	UserID     int       `json:"userId"`
+	Channels   []string  `json:"channels"`
+	Email      string    `json:"email,omitempty"`
+	WebhookURL string    `json:"webhookUrl,omitempty"`
+	QuietStart string    `json:"quietStart,omitempty"`
+	QuietEnd   string    `json:"quietEnd,omitempty"`
+	Timezone   string    `json:"timezone,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type Notification struct {
+	ID            int       `json:"id"`
+	EventID       int       `json:"eventId"`
+	UserID        int       `json:"userId"`
+	Title         string    `json:"title"`
+	Date          time.Time `json:"date"`
+	Channel       string    `json:"channel"`
+	Subject       string    `json:"subject"`
+	Body          string    `json:"body"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	Error         string    `json:"error,omitempty"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Reminder asks for a notification Offset before the event starts, over
+// Channel or over every enabled channel when Channel is empty.
+type Reminder struct {
+	Offset  time.Duration
+	Channel string
+}
+
+type BatchMode string
+
+const (
+	Partial BatchMode = "partial"
+	Atomic  BatchMode = "atomic"
+)
+
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// EventFields are the editable fields of an event; an update leaves the
+// zero-valued ones unchanged.
+type EventFields struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Date        time.Time `json:"date,omitempty"`
+	Duration    string    `json:"duration,omitempty"`
+}
+
+type BatchOperation struct {
+	Op    string       `json:"op"`
+	ID    int          `json:"id,omitempty"`
+	Event *EventFields `json:"event,omitempty"`
+}
+
+type BatchResult struct {
+	Op    string `json:"op"`
+	Event *Event `json:"event,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchReport holds a result per operation, in request order.
+type BatchReport struct {
+	Applied int           `json:"applied"`
+	Failed  int           `json:"failed"`
+	Results []BatchResult `json:"results"`
+}
@@ -0,0 +1,41 @@
+package calendarclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+func (c *Client) NotificationPreferences(ctx context.Context, userID int) (*Preferences, error) {
+	var preferences Preferences
+	err := c.do(ctx, call{
+		method: http.MethodGet, path: "/notifications/preferences", query: userQuery(userID), out: &preferences,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// SetNotificationPreferences replaces the user's channels and quiet hours; UpdatedAt is ignored.
+func (c *Client) SetNotificationPreferences(ctx context.Context, preferences Preferences) (*Preferences, error) {
+	var updated Preferences
+	err := c.do(ctx, call{
+		method: http.MethodPut, path: "/notifications/preferences", body: preferences, out: &updated,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) ListNotifications(ctx context.Context, userID int, limit int) ([]Notification, error) {
+	query := userQuery(userID)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var notifications []Notification
+	err := c.do(ctx, call{method: http.MethodGet, path: "/notifications", query: query, out: &notifications})
+	return notifications, err
+}
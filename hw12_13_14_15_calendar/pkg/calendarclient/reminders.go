@@ -0,0 +1,58 @@
+package calendarclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type reminderPayload struct {
+	Offset  string `json:"offset"`
+	Channel string `json:"channel,omitempty"`
+}
+
+func (c *Client) EventReminders(ctx context.Context, id int, userID int) ([]Reminder, error) {
+	var payloads []reminderPayload
+	err := c.do(ctx, call{
+		method: http.MethodGet, path: "/events/reminders", query: eventQuery(id, userID), out: &payloads,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeReminders(payloads)
+}
+
+// SetEventReminders replaces the reminders of an event; an empty list restores the default lead time reminder.
+func (c *Client) SetEventReminders(ctx context.Context, id int, userID int, reminders []Reminder) ([]Reminder, error) {
+	request := struct {
+		Reminders []reminderPayload `json:"reminders"`
+	}{Reminders: make([]reminderPayload, 0, len(reminders))}
+	for _, reminder := range reminders {
+		request.Reminders = append(request.Reminders, reminderPayload{
+			Offset:  reminder.Offset.String(),
+			Channel: reminder.Channel,
+		})
+	}
+
+	var payloads []reminderPayload
+	err := c.do(ctx, call{
+		method: http.MethodPut, path: "/events/reminders", query: eventQuery(id, userID), body: request, out: &payloads,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeReminders(payloads)
+}
+
+func decodeReminders(payloads []reminderPayload) ([]Reminder, error) {
+	reminders := make([]Reminder, 0, len(payloads))
+	for _, payload := range payloads {
+		offset, err := time.ParseDuration(payload.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("decode reminder offset %q: %w", payload.Offset, err)
+		}
+		reminders = append(reminders, Reminder{Offset: offset, Channel: payload.Channel})
+	}
+	return reminders, nil
+}
@@ -0,0 +1,44 @@
+package calendarclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// CreateWebhook subscribes a URL to event changes. The returned webhook is the
+// only one carrying the signing secret.
+func (c *Client) CreateWebhook(ctx context.Context, request WebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/webhooks", body: request, out: &webhook}); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context, userID int) ([]Webhook, error) {
+	var webhooks []Webhook
+	err := c.do(ctx, call{method: http.MethodGet, path: "/webhooks", query: userQuery(userID), out: &webhooks})
+	return webhooks, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int, userID int) error {
+	query := userQuery(userID)
+	query.Set("id", strconv.Itoa(id))
+	return c.do(ctx, call{method: http.MethodDelete, path: "/webhooks", query: query})
+}
+
+// ListDeliveries returns deliveries newest first; webhookID 0 lists all of the user's webhooks.
+func (c *Client) ListDeliveries(ctx context.Context, userID int, webhookID int, limit int) ([]Delivery, error) {
+	query := userQuery(userID)
+	if webhookID > 0 {
+		query.Set("webhook_id", strconv.Itoa(webhookID))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var deliveries []Delivery
+	err := c.do(ctx, call{method: http.MethodGet, path: "/webhooks/deliveries", query: query, out: &deliveries})
+	return deliveries, err
+}